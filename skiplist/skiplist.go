@@ -1,23 +1,48 @@
-// This file was automatically generated by genny.
-// Any changes will be lost if this file is regenerated.
-// see https://github.com/cheekybits/genny
-
 package skiplist
 
 import (
-	"bytes"
+	"fmt"
 	"math/rand"
+	"sync/atomic"
+	"time"
 )
 
-//NodeID rapresent a pointer
-type NodeID int
-
-//Node is the main type contained inside the StringSk
+// NodeID rapresent a pointer, it is fixed size so that it can be published
+// atomically to concurrent readers
+type NodeID uint32
+
+// MaxHeight is the maximum number of levels of the SkipList, it matches the
+// 31 next pointers of the on disk node described in index.txt
+const MaxHeight = 31
+
+// Node is the main type contained inside the StringSk, the key used for
+// ordering and the value associated with it are owned by the arena and
+// referenced through the slab, links and the references are always accessed
+// atomically since the slab may move the bytes they point to.
+// Nodes also hold the newest version of their key, seq is the sequence of the
+// write that produced it and older links the versions kept for snapshots. A
+// deleted node is kept linked for the snapshots, or for good when it is a
+// tombstone written by Delete, without a value unless the values order the
+// entries. Expiring nodes hold their unix expiry time
 type Node struct {
-	Next  []NodeID
-	Value []byte
+	Next      []NodeID
+	Span      []uint32
+	keyRef    atomic.Uint64
+	keyLen    uint32
+	value     atomic.Uint64
+	seq       atomic.Uint64
+	older     atomic.Uint32
+	deleted   atomic.Bool
+	tombstone atomic.Bool
+	expires   atomic.Int64
 }
 
+// SkipList errors types
+var (
+	ErrEmptyKey = fmt.Errorf("Key size must be more than 0")
+	ErrFull     = fmt.Errorf("SkipList memory limit reached")
+)
+
 // returns the height of the StringSk
 func (n *Node) height() int {
 	h := len(n.Next)
@@ -30,95 +55,253 @@ func (n *Node) height() int {
 }
 
 func (n *Node) isNotNull(a *Arena, i int) bool {
-	if id := n.Next[i]; id == 0 {
+	id := n.next(i)
+	if id == 0 {
 		return false
 	}
 
-	value := a.ValueFromID(n.Next[i])
-	if size := len(value); size != 0 {
+	if size := a.NodeFromID(id).keyLen; size != 0 {
 		return true
 	}
 
 	return false
 }
 
-func newNode(height int) *Node {
-	return &Node{Next: make([]NodeID, height)}
+// weight returns the number of entries node counts for in the spans, deleted
+// nodes count for none
+func (n *Node) weight() uint32 {
+	if n.deleted.Load() {
+		return 0
+	}
+
+	return 1
+}
+
+// next loads the link at level i
+func (n *Node) next(i int) NodeID {
+	return NodeID(atomic.LoadUint32((*uint32)(&n.Next[i])))
+}
+
+// setNext publishes the link at level i
+func (n *Node) setNext(i int, id NodeID) {
+	atomic.StoreUint32((*uint32)(&n.Next[i]), uint32(id))
 }
 
-// SkipList is the SkipList data structure
+// SkipList is the SkipList data structure, the sentinel has all the
+// MaxHeight levels preallocated and height tracks how many are in use
 type SkipList struct {
 	arena     *Arena
 	stack     []*Node
+	rank      []int
+	head      NodeID
 	sentinel  *Node
-	nodeCount uint
+	height    atomic.Int32
+	nodeCount atomic.Uint64
+	deleted   atomic.Int64
+	seq       uint64
+	snapshots map[uint64]int
+	newest    uint64
+	versioned map[NodeID]struct{}
+	cmp       Comparator
+	eq        func(a, b []byte) bool
+	rnd       *rand.Rand
+	p         float64
+	maxLevel  int
+	memLimit  int64
+	multi     bool
+	tiebreak  Comparator
+	clock     func() time.Time
 }
 
-//New creates new SkipList
+// Conf is a configuration struct to be given when a new SkipList is
+// initialized, zero values select the defaults. The lists never modify it so
+// one Conf can build many lists, except that a Source set in it is shared by
+// them and must not be used by two lists from different goroutines
+type Conf struct {
+	Comparator Comparator       // Comparator orders the keys, bytewise by default
+	BucketSize int              // BucketSize is the number of nodes of the first arena bucket, sized from MemLimit by default
+	MaxNodes   int              // MaxNodes caps the arena size, unlimited when 0
+	MemLimit   int64            // MemLimit caps the bytes used by the list, unlimited when 0
+	Multi      bool             // Multi keeps an entry per write of a key, see FindAll
+	Tiebreak   Comparator       // Tiebreak orders the entries of a key by value in multi mode
+	Clock      func() time.Time // Clock tells the time for the expiry, time.Now by default
+	P          float64          // P is the promotion probability, 0.5 by default
+	MaxLevel   int              // MaxLevel caps the node levels, MaxHeight by default
+	Source     rand.Source      // Source drives the level generation, time seeded by default
+}
+
+// New creates new SkipList
 func New() *SkipList {
+	return NewWithConf(&Conf{})
+}
+
+// NewWithConf creates a new SkipList based on the given configuration
+func NewWithConf(conf *Conf) *SkipList {
+	config := conf.defaults()
+
+	bucketSize, slabSize := arenaSizes(config.MemLimit)
+	if config.BucketSize > 0 {
+		bucketSize = config.BucketSize
+	}
+
 	sk := &SkipList{
-		arena:     newArena(),
-		stack:     make([]*Node, 128),
-		sentinel:  nil,
-		nodeCount: 0,
+		arena:    newArena(bucketSize, config.MaxNodes, slabSize),
+		stack:    make([]*Node, MaxHeight),
+		rank:     make([]int, MaxHeight),
+		sentinel: nil,
+		cmp:      config.Comparator,
+		rnd:      rand.New(config.Source),
+		p:        config.P,
+		maxLevel: config.MaxLevel,
+		memLimit: config.MemLimit,
+		multi:    config.Multi,
+		tiebreak: config.Tiebreak,
+		clock:    config.Clock,
+
+		snapshots: make(map[uint64]int),
+		versioned: make(map[NodeID]struct{}),
 	}
 
-	sk.sentinel = sk.arena.NodeFromID(sk.arena.allocate([]byte{}, 1))
+	sk.eq = equality(config.Comparator)
+
+	sk.head, _ = sk.arena.allocate([]byte{}, nil, MaxHeight-1)
+	sk.sentinel = sk.arena.NodeFromID(sk.head)
+	sk.height.Store(1)
+	sk.rewind()
+
+	// the last node of a level spans to one past the last rank
+	for h := range sk.sentinel.Span {
+		sk.sentinel.Span[h] = 1
+	}
 
 	return sk
 }
 
+// defaults returns a copy of the configuration with the zero values replaced
+// by the defaults, the configuration itself is left untouched so that it can
+// be shared by several lists
+func (config Conf) defaults() Conf {
+	if config.Comparator == nil {
+		config.Comparator = Bytewise
+	}
+
+	if config.P <= 0 || config.P >= 1 {
+		config.P = 0.5
+	}
+
+	if config.MaxLevel <= 0 || config.MaxLevel > MaxHeight {
+		config.MaxLevel = MaxHeight
+	}
+
+	if config.Source == nil {
+		config.Source = rand.NewSource(time.Now().UnixNano())
+	}
+
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	return config
+}
+
 // Size returns the nodeCount of Nodes in the StringSk
-// except for the sentinel Node
+// except for the sentinel Node and the deleted ones kept for snapshots
 func (s *SkipList) Size() uint {
-	return s.nodeCount
+	return uint(int64(s.nodeCount.Load()) - s.deleted.Load())
+}
+
+// Bytes returns the approximate memory held by the list, see Arena.Bytes
+func (s *SkipList) Bytes() int64 {
+	return s.arena.Bytes()
+}
+
+// Full returns true once the nodes and the keys and values of the list use
+// MemLimit bytes or more, writes adding data are refused from then on so that
+// the list can be frozen and flushed. The space given back by removals and
+// replaced values doesn't count, Bytes may be somewhat higher since it
+// counts the memory allocated ahead
+func (s *SkipList) Full() bool {
+	return s.memLimit > 0 && s.arena.inUse() >= s.memLimit
 }
 
 // Height returns the current height of the StringSk
 func (s *SkipList) Height() int {
-	return len(s.sentinel.Next) - 1
+	return int(s.height.Load())
 }
 
-func (s *SkipList) findPrev(value []byte) *Node {
-	n := s.sentinel
-	h := n.height()
+// findPrev returns the id of the last node with a key smaller than key, the
+// sentinel id is returned when there is none
+func (s *SkipList) findPrev(key []byte) NodeID {
+	id, n := s.head, s.sentinel
+	h := s.Height()
 	for ; h >= 0; h-- {
-		for n.isNotNull(s.arena, h) && bytes.Compare(s.arena.ValueFromID(n.Next[h]), value) < 0 {
-			n = s.arena.NodeFromID(n.Next[h])
+		for n.isNotNull(s.arena, h) && s.cmp.Compare(s.arena.KeyFromID(n.next(h)), key) < 0 {
+			id = n.next(h)
+			n = s.arena.NodeFromID(id)
 		}
 	}
 
-	return n
+	return id
 }
 
-// Find tries to look for a value and returns the tuple value, boolean
-// true if the value was found false if it wasnt' found
-func (s *SkipList) Find(value []byte) bool {
-	n := s.findPrev(value)
-	if n.isNotNull(s.arena, 0) && bytes.Equal(s.arena.ValueFromID(n.Next[0]), value) {
-		return true
+// findLast returns the id of the last node of the list, the sentinel id is
+// returned when the list is empty
+func (s *SkipList) findLast() NodeID {
+	id, n := s.head, s.sentinel
+	h := s.Height()
+	for ; h >= 0; h-- {
+		for n.isNotNull(s.arena, h) {
+			id = n.next(h)
+			n = s.arena.NodeFromID(id)
+		}
 	}
 
-	return false
+	return id
+}
+
+// Find tries to look for a key and returns true if the key was found false
+// if it wasnt' found
+func (s *SkipList) Find(key []byte) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+// Get looks for a key and returns the value associated with it and true if
+// the key was found, nil and false otherwise. In multi mode the value of the
+// first entry of the key is returned. The value points into the list and must
+// not be modified
+func (s *SkipList) Get(key []byte) ([]byte, bool) {
+	n := s.arena.NodeFromID(s.findPrev(key))
+	for n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key) {
+		n = s.arena.NodeFromID(n.next(0))
+		if ref, ok := s.liveValue(n); ok {
+			return s.arena.data.value(ref), true
+		}
+	}
+
+	return nil, false
 }
 
 // RangeFind does a range query from start element till end element returns
 // success or failure in form of a boolean and a the list of found values
 // fails optmistiacally meaning if the start value is not found the query
 // does not start at all, if the end value is not found the query run till
-// a bigger value is found or there are no more elements on the list
-func (s SkipList) RangeFind(start []byte, end []byte) (ok bool, found [][]byte) {
-	n := s.findPrev(start)
-	if n.isNotNull(s.arena, 0) && bytes.Equal(s.arena.ValueFromID(n.Next[0]), start) {
-		for ; n.isNotNull(s.arena, 0); n = s.arena.NodeFromID(n.Next[0]) {
-			value := s.arena.ValueFromID(n.Next[0])
-			found = append(found, value)
-
-			if bytes.Equal(value, end) {
+// a bigger value is found or there are no more elements on the list. The
+// keys found point into the list and must not be modified
+func (s *SkipList) RangeFind(start []byte, end []byte) (ok bool, found [][]byte) {
+	n := s.arena.NodeFromID(s.findPrev(start))
+	if n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), start) {
+		for ; n.isNotNull(s.arena, 0); n = s.arena.NodeFromID(n.next(0)) {
+			value := s.arena.KeyFromID(n.next(0))
+			if s.live(s.arena.NodeFromID(n.next(0))) {
+				found = append(found, value)
+			}
+
+			if s.eq(value, end) {
 				return true, found
 			}
 
-			//if bytes.Compare(value, end) == 0 {
+			//if s.cmp.Compare(value, end) == 0 {
 			//	return false, found
 			//}
 		}
@@ -127,82 +310,255 @@ func (s SkipList) RangeFind(start []byte, end []byte) (ok bool, found [][]byte)
 	return
 }
 
+// pickHeight returns the top level of a new node, each level is promoted to
+// the next one with probability p up to maxLevel levels
 func (s *SkipList) pickHeight() int {
-	z := rand.Intn(39751)
-	var (
-		k int
-		m = 1
-	)
+	return randomHeight(s.rnd, s.p, s.maxLevel)
+}
 
-	for (z & m) != 0 {
-		k++
-		m <<= 1
+// randomHeight returns a top level drawn from rnd, see pickHeight
+func randomHeight(rnd *rand.Rand, p float64, maxLevel int) int {
+	h := 0
+	for h < maxLevel-1 && rnd.Float64() < p {
+		h++
 	}
 
-	return int(k) + 1
+	return h
 }
 
-// Insert a new value and returns true or false based on success or failure
+// Insert a new value and returns true or false based on success or failure,
+// inserting a value that is already present or into a full list fails, Full
+// tells the two apart
 func (s *SkipList) Insert(value []byte) bool {
-	n := s.sentinel
-	h := s.sentinel.height()
+	_, found, err := s.put(value, nil, 0, false)
+	return !found && err == nil
+}
 
-	for ; h >= 0; h-- {
-		for n.isNotNull(s.arena, h) && bytes.Compare(s.arena.ValueFromID(n.Next[h]), value) < 0 {
-			n = s.arena.NodeFromID(n.Next[h])
+// Put associates value with key, if the key is already present its value is
+// replaced and the previous one is returned together with replaced set to true.
+// ErrFull is returned once the list reached its MemLimit. The previous value
+// points into the list and must not be modified
+func (s *SkipList) Put(key []byte, value []byte) (prev []byte, replaced bool, err error) {
+	return s.put(key, value, 0, true)
+}
+
+// put looks for the key and links a new node if it is not found, when it is
+// found and upsert is set the value of the existing node gets replaced. The
+// entry expires at the unix time in nanoseconds expires, never when zero
+func (s *SkipList) put(key []byte, value []byte, expires int64, upsert bool) (prev []byte, found bool, err error) {
+	if len(key) == 0 {
+		return nil, false, ErrEmptyKey
+	}
 
+	s.compact()
+	if s.Full() {
+		return nil, false, ErrFull
+	}
+
+	if s.multi {
+		s.seek(key, value)
+		return nil, false, s.insert(key, value, expires, false)
+	}
+
+	if id := s.seek(key, value); id != 0 {
+		// a deleted node only stays linked for the snapshots and an expired
+		// one until it is swept, the key is absent and writing it again adds
+		// a version
+		node := s.arena.NodeFromID(id)
+		if node.deleted.Load() || s.expired(node) {
+			if err := s.revise(id, value, false); err != nil {
+				return nil, false, err
+			}
+			node.expires.Store(expires)
+
+			return nil, false, nil
 		}
-		if n.isNotNull(s.arena, h) && bytes.Equal(s.arena.ValueFromID(n.Next[h]), value) {
-			return false
+
+		prev = s.arena.ValueFromID(id)
+		if upsert {
+			if err := s.revise(id, value, false); err != nil {
+				return nil, false, err
+			}
+			node.expires.Store(expires)
 		}
-		s.stack[h] = n
+
+		return prev, true, nil
 	}
 
-	newID := s.arena.allocate(value, s.pickHeight())
-	new := s.arena.NodeFromID(newID)
-	for s.sentinel.height() < new.height() {
-		if len(s.stack) < new.height() {
-			s.stack = append(s.stack, make([]*Node, 1)...)
-		}
-		s.sentinel.Next = append(s.sentinel.Next, make([]NodeID, 1)...)
-		// basically increamenting stack and StringSk height
-		s.stack[s.sentinel.height()] = s.sentinel
+	return nil, false, s.insert(key, value, expires, false)
+}
+
+// insert links a new node after the nodes left in the stack by a search, the
+// node is a tombstone when tombstone is set
+func (s *SkipList) insert(key []byte, value []byte, expires int64, tombstone bool) error {
+	newID, err := s.arena.allocate(key, value, s.pickHeight())
+	if err != nil {
+		return err
 	}
 
-	for i := 0; i < len(new.Next); i++ {
-		new.Next[i] = s.stack[i].Next[i]
-		s.stack[i].Next[i] = newID
+	s.place(newID, expires, tombstone)
+
+	return nil
+}
+
+// place links node id, allocated and not linked yet, after the nodes left in
+// the stack by a search
+func (s *SkipList) place(id NodeID, expires int64, tombstone bool) {
+	node := s.arena.NodeFromID(id)
+	node.expires.Store(expires)
+	if tombstone {
+		node.deleted.Store(true)
+		node.tombstone.Store(true)
 	}
 
-	s.nodeCount++
+	s.seq++
+	node.seq.Store(s.seq)
+	s.link(id)
 
-	return true
+	if tombstone {
+		s.deleted.Add(1)
+	}
 }
 
-// Remove a new value and returns true or false based on success or failure
-func (s *SkipList) Remove(value []byte) (removed bool) {
-	n := s.sentinel
-	h := s.sentinel.height()
+// Remove a key and returns true or false based on success or failure, while
+// a snapshot can still see the key its node is kept as deleted. In multi mode
+// the first entry of the key is removed
+func (s *SkipList) Remove(key []byte) (removed bool) {
+	return s.removeFirst(key, nil)
+}
 
-	for ; h >= 0; h-- {
-		for n.isNotNull(s.arena, h) && bytes.Compare(s.arena.ValueFromID(n.Next[h]), value) < 0 {
-			n = s.arena.NodeFromID(n.Next[h])
+// removeFirst removes the first entry of key whose value satisfies match,
+// every entry does when match is nil
+func (s *SkipList) removeFirst(key []byte, match func(value []byte) bool) bool {
+	s.search(key)
+
+	n := s.stack[0]
+	for n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key) {
+		id := n.next(0)
+		n = s.arena.NodeFromID(id)
 
+		if !s.live(n) || (match != nil && !match(s.arena.ValueFromID(id))) {
+			continue
 		}
-		if n.isNotNull(s.arena, h) && bytes.Equal(s.arena.ValueFromID(n.Next[h]), value) {
-			next := s.arena.NodeFromID(n.Next[h])
-			n.Next[h] = next.Next[h]
-			if n == s.sentinel && n.isNotNull(s.arena, h) {
-				s.sentinel.Next = s.sentinel.Next[:s.Height()]
-			}
 
-			removed = true
+		if id != s.stack[0].next(0) {
+			s.locate(id)
+		}
+
+		return s.remove(id)
+	}
+
+	return false
+}
+
+// remove removes a node found by search, it is unlinked unless a snapshot
+// can still see it
+func (s *SkipList) remove(id NodeID) bool {
+	node := s.arena.NodeFromID(id)
+	if node.older.Load() == 0 && !s.retained(node.seq.Load()) {
+		s.unlink(id)
+		return true
+	}
+
+	return s.revise(id, nil, true) == nil
+}
+
+// search fills the stack with the last node smaller than key on each level
+// and rank with their positions, it returns the node holding key if any
+func (s *SkipList) search(key []byte) NodeID {
+	n, r := s.sentinel, 0
+	for h := s.Height(); h >= 0; h-- {
+		for n.isNotNull(s.arena, h) && s.cmp.Compare(s.arena.KeyFromID(n.next(h)), key) < 0 {
+			r += int(n.Span[h])
+			n = s.arena.NodeFromID(n.next(h))
+		}
+		s.stack[h] = n
+		s.rank[h] = r
+	}
+
+	if n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key) {
+		return n.next(0)
+	}
+
+	return 0
+}
+
+// link links a new node after the nodes found by search, spans count the
+// entries covered by each link that are not deleted, a missing link covering
+// the ones up to one past the last node. Ranks are counted the same way so
+// that Rank and Select skip the deleted nodes kept for snapshots
+func (s *SkipList) link(id NodeID) {
+	node := s.arena.NodeFromID(id)
+	height := s.Height()
+	w := node.weight()
+
+	// basically increamenting stack and StringSk height
+	for h := height + 1; h <= node.height(); h++ {
+		s.stack[h] = s.sentinel
+		s.rank[h] = 0
+		s.sentinel.Span[h] = uint32(s.Size()) + 1
+	}
+
+	// links are published bottom up so that readers always find the node
+	// on the lower levels first
+	for h := 0; h <= node.height(); h++ {
+		prev := s.stack[h]
+		node.Span[h] = prev.Span[h] - uint32(s.rank[0]-s.rank[h])
+		prev.Span[h] = uint32(s.rank[0]-s.rank[h]) + w
+
+		node.setNext(h, prev.next(h))
+		prev.setNext(h, id)
+	}
+
+	for h := node.height() + 1; h <= height; h++ {
+		s.stack[h].Span[h] += w
+	}
+
+	// the node precedes the keys that follow it, the next write starts there
+	r := s.rank[0] + int(w)
+	for h := 0; h <= node.height(); h++ {
+		s.stack[h], s.rank[h] = node, r
+	}
+
+	if node.height() > height {
+		s.height.Store(int32(node.height()))
+	}
+
+	s.nodeCount.Add(1)
+	s.verify()
+}
+
+// unlink removes a node found by search, links are unpublished top down and
+// the removed node keeps its own links so that readers standing on it can
+// still move forward
+func (s *SkipList) unlink(id NodeID) {
+	node := s.arena.NodeFromID(id)
+	w := node.weight()
+	for h := s.Height(); h >= 0; h-- {
+		prev := s.stack[h]
+		if h <= node.height() && prev.next(h) == id {
+			prev.Span[h] += node.Span[h] - w
+			prev.setNext(h, node.next(h))
+		} else {
+			prev.Span[h] -= w
 		}
 	}
 
-	if removed {
-		s.nodeCount--
+	if node.deleted.Load() {
+		s.deleted.Add(-1)
 	}
+	delete(s.versioned, id)
+	s.arena.retire(id)
+	s.shrink()
+	s.nodeCount.Add(^uint64(0))
+	s.verify()
+}
 
-	return removed
+// shrink lowers the height past the levels left empty by an unlinking
+func (s *SkipList) shrink() {
+	h := s.Height()
+	for h > 1 && !s.sentinel.isNotNull(s.arena, h) {
+		h--
+	}
+	s.height.Store(int32(h))
 }
@@ -1,60 +1,366 @@
 package skiplist
 
-const nodesForBucket = 1024 * 128
+import (
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+	"unsafe"
+)
 
-//Arena is an allocator type
-type Arena struct {
-	nodes     [][]Node
-	available int
-	current   int
+// The first bucket holds nodesForFirstBucket nodes, every following one twice
+// as many as the previous one up to nodesForBucket
+const (
+	nodesForFirstBucket = 1024
+	nodesForBucket      = 1024 * 128
+)
+
+// bytesForNode is the footprint of a node without its links, each level adds
+// a link and a span
+const bytesForNode = int64(unsafe.Sizeof(Node{}))
+
+// Arena errors types
+var (
+	ErrArenaFull = fmt.Errorf("Arena max nodes limit reached")
+)
+
+// pool holds the nodes of an arena, they live in buckets that are added on
+// demand and double in size up to nodesForBucket nodes, so that small lists
+// stay small and big ones don't add buckets too often. The bucket directory
+// is replaced atomically so that concurrent readers never see it while it
+// grows. Removed nodes are retired
+// and reused by later allocations with as many levels once no reader can
+// still reach them, see epochs
+type pool[N any] struct {
+	buckets    atomic.Pointer[[][]N]
+	bucketSize int
+	doublings  int
+	capacity   atomic.Int64
+	maxNodes   int
+	current    int
+	free       [MaxHeight + 1][]NodeID
+	limbo      []retired
+	epochs
+}
+
+// retired is a node waiting in limbo with its number of levels and the epoch
+// it was retired in
+type retired struct {
+	id     NodeID
+	levels int
+	epoch  uint64
 }
 
-func newArena() *Arena {
-	arena := &Arena{
-		nodes:     make([][]Node, 0),
-		available: 0,
-		current:   0,
+// epochs tells when the readers that may have seen a retired item are all
+// gone. A reader enters the current epoch and exits it when done, the writer
+// moves to the next epoch once nobody is left in the previous one, so that
+// readers are at most one epoch behind. An item retired in epoch e is then
+// unreachable once the epoch reaches e+2, even while newer readers keep
+// overlapping, only the readers that entered before it was retired hold it
+type epochs struct {
+	epoch  atomic.Uint64
+	active [3]atomic.Int64
+}
+
+// enter marks the beginning of a read that may outlive a mutation and returns
+// the epoch to give back to exit
+func (e *epochs) enter() uint64 {
+	for {
+		epoch := e.epoch.Load()
+		e.active[epoch%3].Add(1)
+		if e.epoch.Load() == epoch {
+			return epoch
+		}
+		e.active[epoch%3].Add(-1)
 	}
+}
 
-	nodes := make([]Node, nodesForBucket, nodesForBucket)
-	arena.nodes = append(arena.nodes, nodes)
-	arena.available = nodesForBucket
+// exit marks the end of a read started with enter in epoch
+func (e *epochs) exit(epoch uint64) {
+	e.active[epoch%3].Add(-1)
+}
 
-	return arena
+// advance moves to the next epoch and returns true when no reader is left in
+// the previous one, it is only called by the writer
+func (e *epochs) advance() bool {
+	epoch := e.epoch.Load()
+	if e.active[(epoch+2)%3].Load() != 0 {
+		return false
+	}
 
+	e.epoch.Store(epoch + 1)
+	return true
 }
 
-//NodeFromID return the inderlying node pointer
-func (a *Arena) NodeFromID(id NodeID) *Node {
+// safe returns true when no reader can still reach an item retired in epoch,
+// the epoch is advanced as far as the readers allow to get there
+func (e *epochs) safe(epoch uint64) bool {
+	for e.epoch.Load() < epoch+2 {
+		if !e.advance() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// init sets the pool up with a first bucket of bucketSize nodes, the buckets
+// double in size until they reach nodesForBucket nodes or bucketSize when it
+// is bigger
+func (p *pool[N]) init(bucketSize int, maxNodes int) {
+	if bucketSize <= 0 {
+		bucketSize = nodesForFirstBucket
+	}
+
+	p.bucketSize = bucketSize
+	p.maxNodes = maxNodes
+	p.doublings = 0
+	for bucketSize<<p.doublings < nodesForBucket {
+		p.doublings++
+	}
+
+	buckets := [][]N{make([]N, bucketSize)}
+	p.buckets.Store(&buckets)
+	p.capacity.Store(int64(bucketSize))
+}
+
+// sizeOf returns the number of nodes of bucket
+func (p *pool[N]) sizeOf(bucket int) int {
+	if bucket > p.doublings {
+		bucket = p.doublings
+	}
+
+	return p.bucketSize << bucket
+}
+
+// node returns the node of id, the buckets that double hold the first
+// bucketSize*(2^doublings-1) nodes and the following ones have the same size
+func (p *pool[N]) node(id NodeID) *N {
 	number := int(id) - 1
-	bucket := number / nodesForBucket
-	index := number % nodesForBucket
 
-	return &a.nodes[bucket][index]
+	var bucket, index int
+	if doubled := p.bucketSize * (1<<p.doublings - 1); number < doubled {
+		bucket = bits.Len(uint(number/p.bucketSize+1)) - 1
+		index = number - p.bucketSize*(1<<bucket-1)
+	} else {
+		size := p.bucketSize << p.doublings
+		bucket = p.doublings + (number-doubled)/size
+		index = (number - doubled) % size
+	}
+
+	return &(*p.buckets.Load())[bucket][index]
+}
+
+// take returns a node for the given number of levels, reused is true when it
+// comes from the free lists and still holds the fields of its previous life
+func (p *pool[N]) take(levels int) (id NodeID, reused bool, err error) {
+	p.reclaim()
+	if free := p.free[levels]; len(free) > 0 {
+		p.free[levels] = free[:len(free)-1]
+		return free[len(free)-1], true, nil
+	}
+
+	if p.maxNodes > 0 && p.current >= p.maxNodes {
+		return 0, false, ErrArenaFull
+	}
+
+	if int64(p.current) == p.capacity.Load() {
+		buckets := *p.buckets.Load()
+		size := p.sizeOf(len(buckets))
+
+		grown := make([][]N, len(buckets), len(buckets)+1)
+		copy(grown, buckets)
+		grown = append(grown, make([]N, size))
+		p.buckets.Store(&grown)
+		p.capacity.Add(int64(size))
+	}
+
+	p.current++
+
+	return NodeID(p.current), false, nil
+}
+
+// release hands back a node that has been unlinked from every level, readers
+// that entered before the unlinking may still be standing on it so the node
+// waits in limbo until they are gone
+func (p *pool[N]) release(id NodeID, levels int) {
+	p.limbo = append(p.limbo, retired{id, levels, p.epoch.Load()})
+	p.reclaim()
+}
+
+// reclaim moves the retired nodes no reader can reach anymore to the free
+// lists, readers that enter afterwards can't reach them since they were
+// unlinked before. The limbo is in retirement order so it drains from the
+// front
+func (p *pool[N]) reclaim() {
+	n := 0
+	for n < len(p.limbo) && p.safe(p.limbo[n].epoch) {
+		r := p.limbo[n]
+		p.free[r.levels] = append(p.free[r.levels], r.id)
+		n++
+	}
+
+	if n > 0 {
+		p.limbo = append(p.limbo[:0], p.limbo[n:]...)
+	}
+}
+
+// Arena is an allocator type, nodes are held in a pool and keys and values are
+// copied into the data slab. Every node owns its key and its value in the
+// slab, a version owns the value it took from its node and a deleted node
+// holds no value unless the values order the entries, the space is given back
+// when they are retired or when the value is replaced
+type Arena struct {
+	pool[Node]
+	data      *slab
+	nodes     atomic.Int64
+	linkBytes atomic.Int64
+}
+
+// newArena creates an arena with buckets of bucketSize nodes and slab chunks
+// of slabSize bytes, maxNodes caps the memory in use when greater than zero
+func newArena(bucketSize int, maxNodes int, slabSize int) *Arena {
+	arena := &Arena{}
+	arena.data = newSlab(slabSize, &arena.epochs)
+	arena.init(bucketSize, maxNodes)
+
+	return arena
+}
+
+// NodeFromID return the inderlying node pointer
+func (a *Arena) NodeFromID(id NodeID) *Node {
+	return a.node(id)
+}
+
+// KeyFromID return the inderlying node key, it must not be modified
+func (a *Arena) KeyFromID(id NodeID) []byte {
+	return a.keyOf(a.NodeFromID(id))
 }
 
-//ValueFromID return the inderlying node value
+// keyOf returns the key of node, it must not be modified
+func (a *Arena) keyOf(node *Node) []byte {
+	return a.data.bytes(node.keyRef.Load(), int(node.keyLen))
+}
+
+// ValueFromID return the inderlying node value, it must not be modified
 func (a *Arena) ValueFromID(id NodeID) []byte {
-	number := int(id) - 1
-	bucket := number / nodesForBucket
-	index := number % nodesForBucket
+	return a.data.value(a.NodeFromID(id).value.Load())
+}
+
+// setValue copies value into the slab and publishes it, the previous value
+// is left to the caller
+func (a *Arena) setValue(id NodeID, value []byte) {
+	a.NodeFromID(id).value.Store(a.data.allocValue(value))
+}
+
+// setKey copies key into the slab, the node must not be reachable yet
+func (a *Arena) setKey(id NodeID, key []byte) {
+	node := a.NodeFromID(id)
+	node.keyRef.Store(a.data.alloc(key))
+	node.keyLen = uint32(len(key))
+}
 
-	return a.nodes[bucket][index].Value
+// relocate copies the key and the value of node held by the sparse chunks to
+// the current one and publishes them, readers keep reading the old copies
+// until they exit their epoch
+func (a *Arena) relocate(node *Node, sparse []bool) {
+	moved := func(ref uint64) bool {
+		index := int(ref >> 32)
+		return index < len(sparse) && sparse[index]
+	}
+
+	if ref := node.keyRef.Load(); node.keyLen > 0 && moved(ref) {
+		node.keyRef.Store(a.data.alloc(a.keyOf(node)))
+		a.data.free(ref, int(node.keyLen))
+	}
+
+	if ref := node.value.Load(); ref != 0 && moved(ref-1) {
+		node.value.Store(a.data.allocValue(a.data.value(ref)))
+		a.data.freeValue(ref)
+	}
 }
 
-func (a *Arena) allocate(data []byte, height int) NodeID {
-	a.available--
-	newID := NodeID(a.current + 1)
+func (a *Arena) allocate(key []byte, value []byte, height int) (NodeID, error) {
+	newID, reused, err := a.take(height + 1)
+	if err != nil {
+		return 0, err
+	}
+
 	node := a.NodeFromID(newID)
-	node.Value = data
-	a.current++
+	if reused {
+		for i := range node.Next {
+			node.Next[i] = 0
+			node.Span[i] = 0
+		}
+		node.seq.Store(0)
+		node.older.Store(0)
+		node.deleted.Store(false)
+		node.tombstone.Store(false)
+		node.expires.Store(0)
+	} else {
+		node.Next = make([]NodeID, height+1)
+		node.Span = make([]uint32, height+1)
+		a.nodes.Add(1)
+		a.linkBytes.Add(nodeBytes(height) - bytesForNode)
+	}
+
+	a.setKey(newID, key)
+	a.setValue(newID, value)
+
+	return newID, nil
+}
 
-	for i := 0; i <= height; i++ {
-		a.current++
-		// good candidate for optimization
-		node.Next = append(node.Next, NodeID(a.current))
-		a.available--
+// Bytes returns the approximate memory held by the arena, the buckets of
+// nodes as a whole, the links of the nodes taken from them and the chunks of
+// the slab. Buckets and chunks are allocated upfront so an empty arena
+// already holds one of each
+func (a *Arena) Bytes() int64 {
+	return a.capacity.Load()*bytesForNode + a.linkBytes.Load() + a.data.allocated.Load()
+}
+
+// inUse returns the approximate memory used by the nodes taken from the
+// buckets, removed ones are counted since they are kept for reuse, and by the
+// keys and values held by the slab
+func (a *Arena) inUse() int64 {
+	return a.nodes.Load()*bytesForNode + a.linkBytes.Load() + a.data.used.Load()
+}
+
+// arenaSizes returns the first bucket size and the slab chunk size of a list
+// limited to memLimit bytes, the first bucket and chunk are allocated upfront
+// and take at most a sixteenth of the limit each. The defaults are kept
+// without a limit
+func arenaSizes(memLimit int64) (bucketSize int, slabSize int) {
+	if memLimit <= 0 {
+		return nodesForFirstBucket, bytesForSlab
+	}
+
+	share := memLimit / 16
+	bucketSize, slabSize = nodesForFirstBucket, bytesForSlab
+	if nodes := share / bytesForNode; nodes < int64(bucketSize) {
+		bucketSize = 16
+		if nodes > 16 {
+			bucketSize = int(nodes)
+		}
 	}
+	if share < int64(slabSize) {
+		slabSize = 4096
+		if share > 4096 {
+			slabSize = int(share)
+		}
+	}
+
+	return bucketSize, slabSize
+}
+
+// nodeBytes returns the footprint of a node with the given top level
+func nodeBytes(height int) int64 {
+	return bytesForNode + int64(height+1)*int64(unsafe.Sizeof(NodeID(0))+unsafe.Sizeof(uint32(0)))
+}
 
-	return newID
+// retire hands back a node that has been unlinked from every level, or a
+// version no snapshot needs, with its key and its value, see pool.release
+func (a *Arena) retire(id NodeID) {
+	node := a.NodeFromID(id)
+	a.data.free(node.keyRef.Load(), int(node.keyLen))
+	a.data.freeValue(node.value.Load())
+	a.release(id, len(node.Next))
 }
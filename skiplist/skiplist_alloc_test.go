@@ -0,0 +1,261 @@
+package skiplist
+
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func freeNodes(a *Arena) int {
+	free := 0
+	for _, ids := range a.free {
+		free += len(ids)
+	}
+
+	return free
+}
+
+func TestArenaReuse(t *testing.T) {
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		if ok := sk.Remove([]byte(fmt.Sprintf("%04d", i))); !ok {
+			t.Fatal("Failed to remove value")
+		}
+	}
+
+	if free := freeNodes(sk.arena); free != 1000 {
+		t.Fatalf("Expected 1000 free nodes got %v", free)
+	}
+
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	if freeNodes(sk.arena) == 1000 {
+		t.Fatal("Free nodes were not reused")
+	}
+	for i := 0; i < 1000; i++ {
+		if !sk.Find([]byte(fmt.Sprintf("%04d", i))) {
+			t.Fatal("Value inserted not found")
+		}
+	}
+}
+
+func TestArenaIteratorPinsNodes(t *testing.T) {
+	sk := New()
+	for i := 0; i < 10; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	it := sk.NewIterator()
+	it.SeekGE([]byte("0005"))
+	sk.Remove([]byte("0005"))
+
+	if len(sk.arena.limbo) != 1 || freeNodes(sk.arena) != 0 {
+		t.Fatal("Node reachable by an iterator was reclaimed")
+	}
+
+	sk.Insert([]byte("0005a"))
+	if it.Next(); string(it.Key()) != "0005a" && string(it.Key()) != "0006" {
+		t.Fatalf("Iterator lost its position %v", string(it.Key()))
+	}
+
+	it.Close()
+	sk.Insert([]byte("0010"))
+	if len(sk.arena.limbo) != 0 {
+		t.Fatal("Node not reclaimed after the iterator was closed")
+	}
+}
+
+func TestArenaGrow(t *testing.T) {
+	sk := NewWithConf(&Conf{BucketSize: 16})
+	for i := 0; i < 1000; i++ {
+		if ok := sk.Insert([]byte(fmt.Sprintf("%04d", i))); !ok {
+			t.Fatal("Failed to insert New value")
+		}
+	}
+
+	// the buckets double from 16 nodes, 16+32+...+512 hold the first 1008
+	if buckets := len(*sk.arena.buckets.Load()); buckets != 6 {
+		t.Fatalf("Expected the arena to grow to 6 buckets got %v", buckets)
+	}
+	for i := 0; i < 1000; i++ {
+		if !sk.Find([]byte(fmt.Sprintf("%04d", i))) {
+			t.Fatal("Value inserted not found")
+		}
+	}
+}
+
+func TestArenaMaxNodes(t *testing.T) {
+	sk := NewWithConf(&Conf{BucketSize: 4, MaxNodes: 11})
+	for i := 0; i < 10; i++ {
+		if _, _, err := sk.Put([]byte(fmt.Sprintf("%04d", i)), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := sk.Put([]byte("0010"), nil); err != ErrArenaFull {
+		t.Fatalf("Expected ErrArenaFull got %v", err)
+	}
+	if sk.Insert([]byte("0010")) {
+		t.Fatal("Insert into a full arena should fail")
+	}
+
+	sk.Remove([]byte("0000"))
+	if sk.Size() != 9 {
+		t.Fatalf("Expected 9 keys got %v", sk.Size())
+	}
+}
+
+func TestArenaInUse(t *testing.T) {
+	sk := New()
+	empty := sk.arena.inUse()
+
+	sk.Put([]byte("key"), make([]byte, 1000))
+	if grown := sk.arena.inUse() - empty; grown < 1003 || grown > 1003+nodeBytes(MaxHeight)+binary.MaxVarintLen64 {
+		t.Fatalf("Unexpected growth %v", grown)
+	}
+
+	// the node is kept for reuse, the key and the value are given back
+	sk.Remove([]byte("key"))
+	if kept := sk.arena.inUse() - empty; kept <= 0 || kept > nodeBytes(MaxHeight) {
+		t.Fatalf("Removed nodes are kept for reuse, unexpected %v bytes", kept)
+	}
+}
+
+func TestArenaBytes(t *testing.T) {
+	sk := New()
+
+	// the first bucket and slab chunk are held before any write
+	// and the links of the sentinel, nothing more
+	first := nodesForFirstBucket*bytesForNode + bytesForSlab
+	if empty := sk.Bytes(); empty < first || empty > first+nodeBytes(MaxHeight) {
+		t.Fatalf("Expected the first bucket and chunk counted got %v", empty)
+	}
+
+	// a big key gets a chunk of its own, the first one stays in use
+	sk.Put([]byte("key"), nil)
+	before := sk.Bytes()
+	sk.Put(make([]byte, 2*bytesForSlab), nil)
+	if grown := sk.Bytes() - before; grown < 2*bytesForSlab {
+		t.Fatalf("Expected the new chunk counted got %v", grown)
+	}
+
+	limited := NewWithConf(&Conf{MemLimit: 1024 * 1024})
+	if held := limited.Bytes(); held > 1024*1024/8+nodeBytes(MaxHeight) {
+		t.Fatalf("The first bucket and chunk should follow MemLimit got %v", held)
+	}
+}
+
+func TestMemLimit(t *testing.T) {
+	sk := NewWithConf(&Conf{MemLimit: 64 * 1024})
+	value := make([]byte, 1000)
+
+	count := 0
+	for ; ; count++ {
+		_, _, err := sk.Put([]byte(fmt.Sprint(count)), value)
+		if err == ErrFull {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count < 50 || count > 64 {
+		t.Fatalf("Unexpected number of keys %v", count)
+	}
+
+	if !sk.Full() || sk.Insert([]byte("a")) {
+		t.Fatal("Full list should refuse inserts")
+	}
+
+	if !sk.Remove([]byte("0")) || !sk.Find([]byte("1")) {
+		t.Fatal("Full list should still serve removes and reads")
+	}
+
+	if stats := sk.Stats(); stats.Bytes != sk.Bytes() {
+		t.Fatalf("Expected %v bytes in stats got %v", sk.Bytes(), stats.Bytes)
+	}
+}
+
+func TestArenaOverlappingReaders(t *testing.T) {
+	sk := New()
+	for i := 0; i < 100; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	// an iterator is always open but each one opens after some removals,
+	// the nodes removed before it can be reused while it is open
+	it := sk.NewIterator()
+	for i := 0; i < 50; i++ {
+		sk.Remove([]byte(fmt.Sprintf("%04d", i)))
+
+		next := sk.NewIterator()
+		it.Close()
+		it = next
+	}
+	defer it.Close()
+
+	sk.Insert([]byte("0100"))
+	if len(sk.arena.limbo) > 2 {
+		t.Fatalf("Expected the limbo to drain got %v nodes", len(sk.arena.limbo))
+	}
+}
+
+func TestArenaIteratorFinalized(t *testing.T) {
+	sk := New()
+	for i := 0; i < 10; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	func() {
+		it := sk.NewIterator()
+		it.First()
+	}()
+	sk.Remove([]byte("0005"))
+
+	for i := 0; len(sk.arena.limbo) != 0; i++ {
+		if i == 100 {
+			t.Fatal("Node not reclaimed after the iterator was dropped")
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+		sk.Insert([]byte(fmt.Sprintf("1%03d", i)))
+	}
+}
+
+func TestPoolBuckets(t *testing.T) {
+	var p pool[int]
+	p.init(16, 0)
+
+	// every node keeps its own slot across the doubling buckets and the ones
+	// of the largest size that follow
+	n := 16*(1<<p.doublings-1) + 3*(16<<p.doublings)
+	for i := 1; i <= n; i++ {
+		id, _, err := p.take(1)
+		if err != nil || int(id) != i {
+			t.Fatalf("Expected node %v got %v %v", i, id, err)
+		}
+		*p.node(id) = i
+	}
+
+	for i := 1; i <= n; i++ {
+		if v := *p.node(NodeID(i)); v != i {
+			t.Fatalf("Node %v holds %v", i, v)
+		}
+	}
+
+	buckets := *p.buckets.Load()
+	if len(buckets) != p.doublings+3 || len(buckets[len(buckets)-1]) != nodesForBucket {
+		t.Fatalf("Unexpected buckets %v", len(buckets))
+	}
+	if p.capacity.Load() != int64(n) {
+		t.Fatalf("Expected a capacity of %v got %v", n, p.capacity.Load())
+	}
+}
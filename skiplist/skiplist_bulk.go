@@ -0,0 +1,153 @@
+package skiplist
+
+import "fmt"
+
+// BulkLoad errors types
+var (
+	ErrUnsorted  = fmt.Errorf("Keys must be given in ascending order")
+	ErrDuplicate = fmt.Errorf("Keys must be unique")
+)
+
+// SortedSource yields the key value pairs given to BulkLoad in key order,
+// Next advances to the following pair and returns false once there are no
+// more
+type SortedSource interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+}
+
+// BulkLoad builds a SkipList from the pairs of src which must come in
+// ascending key order according to the configured comparator. Every node is
+// linked at the tail of its levels so the list is built in O(n) without
+// searching, unsorted or duplicated keys stop the build with an error. In
+// multi mode duplicated keys are accepted, in value order with a tiebreak.
+// The build stops with ErrFull once the list reaches its MemLimit
+func BulkLoad(src SortedSource, config *Conf) (*SkipList, error) {
+	s := NewWithConf(config)
+	b := newBuilder(s)
+
+	var prev, prevValue []byte
+	for src.Next() {
+		key := src.Key()
+		if len(key) == 0 {
+			return nil, ErrEmptyKey
+		}
+
+		if prev != nil {
+			c := s.cmp.Compare(prev, key)
+			if c == 0 && s.multi && s.tiebreak != nil {
+				c = s.tiebreak.Compare(prevValue, src.Value())
+			}
+
+			switch {
+			case c == 0 && !s.multi:
+				return nil, ErrDuplicate
+			case c > 0:
+				return nil, ErrUnsorted
+			}
+		}
+
+		if s.Full() {
+			return nil, ErrFull
+		}
+
+		id, err := s.arena.allocate(key, src.Value(), s.pickHeight())
+		if err != nil {
+			return nil, err
+		}
+
+		b.append(id)
+		prev, prevValue = s.arena.KeyFromID(id), s.arena.ValueFromID(id)
+	}
+	b.finish()
+
+	return s, nil
+}
+
+// SliceSource returns a SortedSource over keys and the matching values,
+// values may be nil or shorter than keys in which case the missing values are
+// nil
+func SliceSource(keys [][]byte, values [][]byte) SortedSource {
+	return &sliceSource{keys: keys, values: values, i: -1}
+}
+
+type sliceSource struct {
+	keys   [][]byte
+	values [][]byte
+	i      int
+}
+
+func (src *sliceSource) Next() bool {
+	src.i++
+	return src.i < len(src.keys)
+}
+
+func (src *sliceSource) Key() []byte {
+	return src.keys[src.i]
+}
+
+func (src *sliceSource) Value() []byte {
+	if src.i < len(src.values) {
+		return src.values[src.i]
+	}
+
+	return nil
+}
+
+// builder links nodes given in key order at the tail of each level, last
+// holds the tail of every level and rank its position, count is the number
+// of nodes linked and live the number of them not deleted
+type builder struct {
+	list  *SkipList
+	last  []NodeID
+	rank  []int
+	count int
+	live  int
+}
+
+func newBuilder(s *SkipList) *builder {
+	b := &builder{
+		list: s,
+		last: make([]NodeID, MaxHeight),
+		rank: make([]int, MaxHeight),
+	}
+
+	for h := range b.last {
+		b.last[h] = s.head
+	}
+
+	return b
+}
+
+// append links id after the current tail of each of its levels
+func (b *builder) append(id NodeID) {
+	s := b.list
+	node := s.arena.NodeFromID(id)
+
+	b.count++
+	b.live += int(node.weight())
+	for h := 0; h <= node.height(); h++ {
+		prev := s.arena.NodeFromID(b.last[h])
+		prev.Span[h] = uint32(b.live - b.rank[h])
+		prev.setNext(h, id)
+		b.last[h], b.rank[h] = id, b.live
+	}
+
+	if node.height() > s.Height() {
+		s.height.Store(int32(node.height()))
+	}
+}
+
+// finish terminates each level after its last node and sets the size
+func (b *builder) finish() {
+	s := b.list
+	for h := 0; h <= s.Height(); h++ {
+		last := s.arena.NodeFromID(b.last[h])
+		last.Span[h] = uint32(b.live + 1 - b.rank[h])
+		last.setNext(h, 0)
+	}
+	s.nodeCount.Store(uint64(b.count))
+	s.rewind()
+	s.verify()
+}
@@ -0,0 +1,80 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBulkLoad(t *testing.T) {
+	var keys, values [][]byte
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%04d", i)))
+		values = append(values, []byte(fmt.Sprint(i)))
+	}
+
+	sk, err := BulkLoad(SliceSource(keys, values), &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sk.Size() != 1000 {
+		t.Fatalf("Expected 1000 keys got %v", sk.Size())
+	}
+
+	for i, key := range keys {
+		if value, ok := sk.Get(key); !ok || string(value) != fmt.Sprint(i) {
+			t.Fatalf("Wrong value for %v got %v", string(key), string(value))
+		}
+
+		if rank := sk.Rank(key); rank != i {
+			t.Fatalf("Expected rank %v for %v got %v", i, string(key), rank)
+		}
+	}
+
+	if !sk.Insert([]byte("0500a")) || !sk.Remove([]byte("0999")) {
+		t.Fatal("Bulk loaded list should accept updates")
+	}
+
+	if key, ok := sk.Select(501); !ok || string(key) != "0500a" {
+		t.Fatalf("Wrong key at 501 got %v", string(key))
+	}
+}
+
+func TestBulkLoadEmpty(t *testing.T) {
+	sk, err := BulkLoad(SliceSource(nil, nil), &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sk.Size() != 0 || !sk.Insert([]byte("a")) {
+		t.Fatal("Empty bulk loaded list should accept inserts")
+	}
+}
+
+func TestBulkLoadRejects(t *testing.T) {
+	keys := [][]byte{[]byte("a"), []byte("c"), []byte("b")}
+	if _, err := BulkLoad(SliceSource(keys, nil), &Conf{}); err != ErrUnsorted {
+		t.Fatalf("Expected ErrUnsorted got %v", err)
+	}
+
+	keys = [][]byte{[]byte("a"), []byte("b"), []byte("b")}
+	if _, err := BulkLoad(SliceSource(keys, nil), &Conf{}); err != ErrDuplicate {
+		t.Fatalf("Expected ErrDuplicate got %v", err)
+	}
+
+	keys = [][]byte{[]byte("c"), []byte("b"), []byte("a")}
+	if _, err := BulkLoad(SliceSource(keys, nil), &Conf{Comparator: Reverse(Bytewise)}); err != nil {
+		t.Fatalf("Reverse order should be accepted got %v", err)
+	}
+}
+
+func TestBulkLoadFull(t *testing.T) {
+	var keys [][]byte
+	for i := 0; i < 20000; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("%05d", i)))
+	}
+
+	if _, err := BulkLoad(SliceSource(keys, nil), &Conf{MemLimit: 64 << 10}); err != ErrFull {
+		t.Fatalf("Expected ErrFull got %v", err)
+	}
+}
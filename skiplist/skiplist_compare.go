@@ -0,0 +1,168 @@
+package skiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Comparator is the interface that describes how keys are ordered inside the
+// SkipList, Compare returns -1, 0 or 1 like bytes.Compare does
+type Comparator interface {
+	Compare(a, b []byte) int
+}
+
+// Equaler is an optional fast path a Comparator can implement when equality
+// is cheaper to check than ordering
+type Equaler interface {
+	Equal(a, b []byte) bool
+}
+
+// Built in comparators, the numeric ones compare the first 8 bytes of the key
+// as a number and break ties bytewise on the rest. Keys too short to hold a
+// number sort before all the others and bytewise among themselves
+var (
+	Bytewise           Comparator = bytewise{}
+	Uint64BigEndian    Comparator = fixed64{order: binary.BigEndian}
+	Uint64LittleEndian Comparator = fixed64{order: binary.LittleEndian}
+	Int64BigEndian     Comparator = fixed64{order: binary.BigEndian, signed: true}
+	Int64LittleEndian  Comparator = fixed64{order: binary.LittleEndian, signed: true}
+)
+
+// equality returns the Equal fast path of c when it has one
+func equality(c Comparator) func(a, b []byte) bool {
+	if e, ok := c.(Equaler); ok {
+		return e.Equal
+	}
+
+	return func(a, b []byte) bool { return c.Compare(a, b) == 0 }
+}
+
+type bytewise struct{}
+
+func (bytewise) Compare(a, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+func (bytewise) Equal(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
+
+type fixed64 struct {
+	order  binary.ByteOrder
+	signed bool
+}
+
+func (f fixed64) Compare(a, b []byte) int {
+	switch {
+	case len(a) < 8 && len(b) < 8:
+		return bytes.Compare(a, b)
+	case len(a) < 8:
+		return -1
+	case len(b) < 8:
+		return 1
+	}
+
+	x, y := f.order.Uint64(a), f.order.Uint64(b)
+	if f.signed {
+		x, y = x^(1<<63), y^(1<<63)
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return bytes.Compare(a[8:], b[8:])
+}
+
+func (f fixed64) Equal(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
+
+// Reverse returns a Comparator that orders the keys the other way around
+func Reverse(c Comparator) Comparator {
+	if e, ok := c.(Equaler); ok {
+		return reverseEqual{reverse{c}, e}
+	}
+
+	return reverse{c}
+}
+
+type reverse struct {
+	c Comparator
+}
+
+func (r reverse) Compare(a, b []byte) int {
+	return r.c.Compare(b, a)
+}
+
+type reverseEqual struct {
+	reverse
+	Equaler
+}
+
+// Tuple returns a Comparator for keys built by EncodeTuple, elements are
+// compared in order each with its own comparator, elements past the given
+// comparators are compared bytewise and a tuple that is a prefix of another
+// sorts first
+func Tuple(elems ...Comparator) Comparator {
+	return tuple(elems)
+}
+
+type tuple []Comparator
+
+func (t tuple) Compare(a, b []byte) int {
+	for i := 0; len(a) > 0 && len(b) > 0; i++ {
+		var x, y []byte
+		x, a = nextElem(a)
+		y, b = nextElem(b)
+
+		c := Bytewise
+		if i < len(t) {
+			c = t[i]
+		}
+
+		if r := c.Compare(x, y); r != 0 {
+			return r
+		}
+	}
+
+	switch {
+	case len(a) > 0:
+		return 1
+	case len(b) > 0:
+		return -1
+	}
+
+	return 0
+}
+
+// nextElem splits the first length prefixed element from the tuple, a
+// malformed prefix makes the rest of the tuple a single element
+func nextElem(t []byte) (elem []byte, rest []byte) {
+	size, n := binary.Uvarint(t)
+	if n <= 0 || uint64(len(t)-n) < size {
+		return t, nil
+	}
+
+	return t[n : n+int(size)], t[n+int(size):]
+}
+
+// EncodeTuple builds a tuple key out of parts, each one prefixed by its
+// length as an uvarint
+func EncodeTuple(parts ...[]byte) []byte {
+	size := 0
+	for _, p := range parts {
+		size += binary.MaxVarintLen64 + len(p)
+	}
+
+	key := make([]byte, 0, size)
+	for _, p := range parts {
+		key = binary.AppendUvarint(key, uint64(len(p)))
+		key = append(key, p...)
+	}
+
+	return key
+}
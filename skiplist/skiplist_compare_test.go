@@ -0,0 +1,93 @@
+package skiplist
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestComparatorLittleEndian(t *testing.T) {
+	sk := NewWithConf(&Conf{Comparator: Uint64LittleEndian})
+	for _, v := range []uint64{300, 2, 70000, 1, 256} {
+		key := make([]byte, 8)
+		binary.LittleEndian.PutUint64(key, v)
+		if ok := sk.Insert(key); !ok {
+			t.Fatal("Failed to insert New value")
+		}
+	}
+
+	expected := []uint64{1, 2, 256, 300, 70000}
+	it := sk.NewIterator()
+	defer it.Close()
+	i := 0
+	for it.First(); it.Valid(); it.Next() {
+		if v := binary.LittleEndian.Uint64(it.Key()); v != expected[i] {
+			t.Fatalf("Expected %v got %v", expected[i], v)
+		}
+		i++
+	}
+}
+
+func TestComparatorInt64(t *testing.T) {
+	a, b := make([]byte, 8), make([]byte, 8)
+	binary.BigEndian.PutUint64(a, uint64(-5&(1<<64-1)))
+	binary.BigEndian.PutUint64(b, 3)
+
+	if Int64BigEndian.Compare(a, b) >= 0 {
+		t.Fatal("-5 should sort before 3")
+	}
+	if Uint64BigEndian.Compare(a, b) <= 0 {
+		t.Fatal("unsigned -5 should sort after 3")
+	}
+}
+
+func TestComparatorReverse(t *testing.T) {
+	sk := NewWithConf(&Conf{Comparator: Reverse(Bytewise)})
+	for _, k := range []string{"b", "c", "a"} {
+		sk.Insert([]byte(k))
+	}
+
+	it := sk.NewIterator()
+	defer it.Close()
+	it.First()
+	if string(it.Key()) != "c" {
+		t.Fatalf("Expected c got %v", string(it.Key()))
+	}
+	if !sk.Find([]byte("a")) {
+		t.Fatal("Value inserted not found")
+	}
+}
+
+func TestComparatorTuple(t *testing.T) {
+	c := Tuple(Bytewise, Reverse(Bytewise))
+	keys := [][]byte{
+		EncodeTuple([]byte("cpu")),
+		EncodeTuple([]byte("cpu"), []byte("b")),
+		EncodeTuple([]byte("cpu"), []byte("a")),
+		EncodeTuple([]byte("cpuz"), []byte("a")),
+	}
+
+	for i := 1; i < len(keys); i++ {
+		if c.Compare(keys[i-1], keys[i]) >= 0 {
+			t.Fatalf("Tuple %v should sort before %v", i-1, i)
+		}
+	}
+}
+
+func TestComparatorShortKeys(t *testing.T) {
+	sk := NewWithConf(&Conf{Comparator: Uint64BigEndian})
+
+	long := make([]byte, 8)
+	binary.BigEndian.PutUint64(long, 0)
+	for _, k := range [][]byte{[]byte("abc"), long, []byte("ab")} {
+		if !sk.Insert(k) {
+			t.Fatalf("Failed to insert %q", k)
+		}
+	}
+
+	checkRange(t, sk.Range(&Bounds{}), "ab", "abc", string(long))
+
+	c := Tuple(Int64LittleEndian)
+	if c.Compare(EncodeTuple([]byte("x")), EncodeTuple(long)) >= 0 {
+		t.Fatal("A short tuple element should sort first")
+	}
+}
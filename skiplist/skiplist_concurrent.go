@@ -0,0 +1,273 @@
+package skiplist
+
+import (
+	"sync"
+	"time"
+)
+
+// ConcurrentSkipList is a SkipList that can be shared between goroutines,
+// writers serialize on a mutex while readers never lock, they rely on the
+// atomic publication of the NodeIDs and of the values done by the writers
+type ConcurrentSkipList struct {
+	mu   sync.Mutex
+	list *SkipList
+}
+
+// NewConcurrent creates a new ConcurrentSkipList
+func NewConcurrent() *ConcurrentSkipList {
+	return NewConcurrentWithConf(&Conf{})
+}
+
+// NewConcurrentWithConf creates a new ConcurrentSkipList based on the given
+// configuration
+func NewConcurrentWithConf(config *Conf) *ConcurrentSkipList {
+	return &ConcurrentSkipList{list: NewWithConf(config)}
+}
+
+// Size returns the number of keys in the list
+func (c *ConcurrentSkipList) Size() uint {
+	return c.list.Size()
+}
+
+// Bytes returns the approximate memory held by the list
+func (c *ConcurrentSkipList) Bytes() int64 {
+	return c.list.Bytes()
+}
+
+// Full returns true once the list reached its MemLimit
+func (c *ConcurrentSkipList) Full() bool {
+	return c.list.Full()
+}
+
+// Height returns the current height of the list
+func (c *ConcurrentSkipList) Height() int {
+	return c.list.Height()
+}
+
+// Find returns true if the key is in the list, it never blocks
+func (c *ConcurrentSkipList) Find(key []byte) bool {
+	defer c.list.arena.exit(c.list.arena.enter())
+
+	return c.list.Find(key)
+}
+
+// Get returns the value associated with key, it never blocks. The value
+// points into the list and must not be modified
+func (c *ConcurrentSkipList) Get(key []byte) ([]byte, bool) {
+	defer c.list.arena.exit(c.list.arena.enter())
+
+	return c.list.Get(key)
+}
+
+// NewIterator returns an iterator that reads the list without locking,
+// it observes the writes published while it moves and keeps the removed
+// nodes from being reused until it is closed
+func (c *ConcurrentSkipList) NewIterator() *Iterator {
+	return c.list.NewIterator()
+}
+
+// Insert a new key and returns true or false based on success or failure
+func (c *ConcurrentSkipList) Insert(key []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.Insert(key)
+}
+
+// Put associates value with key and returns the previous value if any, it
+// points into the list and must not be modified
+func (c *ConcurrentSkipList) Put(key []byte, value []byte) (prev []byte, replaced bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.Put(key, value)
+}
+
+// Remove a key and returns true or false based on success or failure
+func (c *ConcurrentSkipList) Remove(key []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.Remove(key)
+}
+
+// Scan visits in order the keys within b until visit returns false, it never
+// blocks. The keys and values given to visit point into the list and must
+// not be modified
+func (c *ConcurrentSkipList) Scan(b *Bounds, visit func(key []byte, value []byte) bool) int {
+	return c.list.Scan(b, visit)
+}
+
+// Snapshot returns a consistent view of the list, readers of the snapshot
+// never block while the writers keep going
+func (c *ConcurrentSkipList) Snapshot() *Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sn := c.list.Snapshot()
+	sn.release = func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.list.drop(sn.seq)
+	}
+
+	return sn
+}
+
+// RemoveRange removes the keys between start and end, both included, and
+// returns the number of keys removed
+func (c *ConcurrentSkipList) RemoveRange(start []byte, end []byte) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.RemoveRange(start, end)
+}
+
+// FindAll returns the values of every entry of key in multi mode, it never
+// blocks. The values point into the list and must not be modified
+func (c *ConcurrentSkipList) FindAll(key []byte) [][]byte {
+	defer c.list.arena.exit(c.list.arena.enter())
+
+	return c.list.FindAll(key)
+}
+
+// RemoveOne removes the first entry of key holding value in multi mode
+func (c *ConcurrentSkipList) RemoveOne(key []byte, value []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.RemoveOne(key, value)
+}
+
+// Delete writes a tombstone for key
+func (c *ConcurrentSkipList) Delete(key []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.Delete(key)
+}
+
+// NewIteratorWithConf returns an iterator based on the given configuration
+// that reads the list without locking like NewIterator does
+func (c *ConcurrentSkipList) NewIteratorWithConf(config *IteratorConf) *Iterator {
+	return c.list.NewIteratorWithConf(config)
+}
+
+// Floor returns the entry with the biggest key smaller or equal than key, it
+// never blocks. The key and the value point into the list and must not be
+// modified
+func (c *ConcurrentSkipList) Floor(key []byte) ([]byte, []byte, bool) {
+	return c.list.Floor(key)
+}
+
+// Ceiling returns the entry with the smallest key bigger or equal than key,
+// it never blocks. The key and the value point into the list and must not be
+// modified
+func (c *ConcurrentSkipList) Ceiling(key []byte) ([]byte, []byte, bool) {
+	return c.list.Ceiling(key)
+}
+
+// Lower returns the entry with the biggest key strictly smaller than key, it
+// never blocks. The key and the value point into the list and must not be
+// modified
+func (c *ConcurrentSkipList) Lower(key []byte) ([]byte, []byte, bool) {
+	return c.list.Lower(key)
+}
+
+// Higher returns the entry with the smallest key strictly bigger than key, it
+// never blocks. The key and the value point into the list and must not be
+// modified
+func (c *ConcurrentSkipList) Higher(key []byte) ([]byte, []byte, bool) {
+	return c.list.Higher(key)
+}
+
+// Min returns the entry with the smallest key, it never blocks. The key and
+// the value point into the list and must not be modified
+func (c *ConcurrentSkipList) Min() ([]byte, []byte, bool) {
+	return c.list.Min()
+}
+
+// Max returns the entry with the biggest key, it never blocks. The key and
+// the value point into the list and must not be modified
+func (c *ConcurrentSkipList) Max() ([]byte, []byte, bool) {
+	return c.list.Max()
+}
+
+// PopMin removes and returns the entry with the smallest key, the key and
+// the value point into the list and must not be modified
+func (c *ConcurrentSkipList) PopMin() ([]byte, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.PopMin()
+}
+
+// PopMax removes and returns the entry with the biggest key, the key and
+// the value point into the list and must not be modified
+func (c *ConcurrentSkipList) PopMax() ([]byte, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.PopMax()
+}
+
+// PutWithTTL associates value with key for ttl and returns the previous value
+// if any, it points into the list and must not be modified
+func (c *ConcurrentSkipList) PutWithTTL(key []byte, value []byte, ttl time.Duration) (prev []byte, replaced bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.PutWithTTL(key, value, ttl)
+}
+
+// InsertWithTTL inserts key for ttl and returns true or false based on
+// success or failure
+func (c *ConcurrentSkipList) InsertWithTTL(key []byte, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.InsertWithTTL(key, ttl)
+}
+
+// Sweep removes the expired entries and returns their number
+func (c *ConcurrentSkipList) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.Sweep()
+}
+
+// StartSweeper sweeps the list every interval in the background until the
+// returned stop function is called, stop waits for a running sweep to end
+func (c *ConcurrentSkipList) StartSweeper(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-ticker.C:
+				c.Sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		<-exited
+	}
+}
+
+// Verify checks the structure of the list, see SkipList.Verify
+func (c *ConcurrentSkipList) Verify() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.list.Verify()
+}
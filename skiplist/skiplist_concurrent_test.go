@@ -0,0 +1,223 @@
+package skiplist
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentReadWrite(t *testing.T) {
+	sk := NewConcurrent()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := []byte(fmt.Sprintf("%v-%04d", w, i))
+				if _, _, err := sk.Put(key, key); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := []byte(fmt.Sprintf("0-%04d", i))
+				if value, ok := sk.Get(key); ok && !bytes.Equal(value, key) {
+					t.Errorf("Wrong value for %v", string(key))
+					return
+				}
+
+				var prev []byte
+				it := sk.NewIterator()
+				for it.First(); it.Valid(); it.Next() {
+					if prev != nil && bytes.Compare(prev, it.Key()) >= 0 {
+						t.Errorf("Keys out of order %v %v", string(prev), string(it.Key()))
+						return
+					}
+					prev = it.Key()
+				}
+				it.Close()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if sk.Size() != 4000 {
+		t.Fatalf("Expected 4000 keys got %v", sk.Size())
+	}
+}
+
+func TestConcurrentRemove(t *testing.T) {
+	sk := NewConcurrent()
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i += 2 {
+			if !sk.Remove([]byte(fmt.Sprintf("%04d", i))) {
+				t.Errorf("Failed to remove %04d", i)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 1; i < 1000; i += 2 {
+			if !sk.Find([]byte(fmt.Sprintf("%04d", i))) {
+				t.Errorf("Key %04d should never disappear", i)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if sk.Size() != 500 {
+		t.Fatalf("Expected 500 keys got %v", sk.Size())
+	}
+}
+
+func TestConcurrentSharedConf(t *testing.T) {
+	config := &Conf{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		sk := NewConcurrentWithConf(config)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if config.Source != nil || config.Comparator != nil || config.Clock != nil {
+		t.Fatal("The configuration should be left untouched")
+	}
+}
+
+func TestConcurrentCompact(t *testing.T) {
+	sk := NewConcurrent()
+	value := func(i int) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("%04d", i)), 250)
+	}
+	for i := 0; i < 2000; i++ {
+		sk.Put([]byte(fmt.Sprintf("%04d", i)), value(i))
+	}
+
+	// the keys left alone pin the chunks the other ones leave, so that the
+	// writes compact the slab under the readers
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for round := 0; round < 10; round++ {
+			for i := 0; i < 2000; i++ {
+				if i%10 != 0 {
+					sk.Put([]byte(fmt.Sprintf("%04d", i)), value(i))
+				}
+			}
+		}
+	}()
+
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i = (i + 7) % 2000 {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if v, ok := sk.Get([]byte(fmt.Sprintf("%04d", i))); !ok || !bytes.Equal(v, value(i)) {
+					t.Errorf("Wrong value for %04d", i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrentScanDelete(t *testing.T) {
+	sk := NewConcurrent()
+	for i := 0; i < 500; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		sk.Put(key, key)
+	}
+
+	// the snapshot makes Remove keep versions, Delete leaves tombstones, both
+	// drop the value of the node while the readers stand on it
+	sn := sk.Snapshot()
+	defer sn.Release()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for round := 0; round < 200; round++ {
+			for i := 0; i < 500; i++ {
+				key := []byte(fmt.Sprintf("%04d", i))
+				switch (i + round) % 3 {
+				case 0:
+					sk.Put(key, key)
+				case 1:
+					sk.Remove(key)
+				default:
+					sk.Delete(key)
+				}
+			}
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				bad := false
+				sk.Scan(&Bounds{}, func(key []byte, value []byte) bool {
+					bad = !bytes.Equal(key, value)
+					return !bad
+				})
+				if bad {
+					t.Error("Scan returned a live key without its value")
+					return
+				}
+
+				if key, value, ok := sk.Floor([]byte("0250")); ok && !bytes.Equal(key, value) {
+					t.Errorf("Floor returned %s without its value", key)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,6 @@
+//go:build skiplistdebug
+
+package skiplist
+
+// debug makes every mutation verify the list, see SkipList.Verify
+const debug = true
@@ -0,0 +1,67 @@
+package skiplist
+
+// Between writes the stack keeps describing a position of the list, the last
+// node of each level that goes before it and its rank. A search leaves it
+// before the key searched and link moves it past the node it links, so that
+// keys written in increasing order find their predecessors in the stack and
+// near sequential ones a few nodes further. Unlinking nodes that follow the
+// position keeps it valid, relinking the whole list rewinds it
+
+// seek fills the stack like search does, or like searchAfter in multi mode,
+// for a new entry of key and value and returns the node holding key if any
+// outside of multi mode. When key follows the position of the stack the
+// levels are climbed from the bottom until one already precedes key and the
+// walk starts from there, appends don't walk at all. Keys out of order fall
+// back to a search from the sentinel
+func (s *SkipList) seek(key []byte, value []byte) NodeID {
+	before := func(n *Node) bool {
+		return s.cmp.Compare(s.arena.keyOf(n), key) < 0
+	}
+	if s.multi {
+		before = func(n *Node) bool {
+			return s.precedes(n, key, value)
+		}
+	}
+
+	if s.stack[0] != s.sentinel && !before(s.stack[0]) {
+		if s.multi {
+			s.searchAfter(key, value)
+			return 0
+		}
+		return s.search(key)
+	}
+
+	// the levels from l up already hold the last node before key, a level
+	// that does not is walked from the furthest node of the ones above
+	top, l := s.Height(), 0
+	for l <= top && s.stack[l].isNotNull(s.arena, l) && before(s.arena.NodeFromID(s.stack[l].next(l))) {
+		l++
+	}
+
+	n, r := s.sentinel, 0
+	for h := l - 1; h >= 0; h-- {
+		if s.rank[h] > r {
+			n, r = s.stack[h], s.rank[h]
+		}
+		for n.isNotNull(s.arena, h) && before(s.arena.NodeFromID(n.next(h))) {
+			r += int(n.Span[h])
+			n = s.arena.NodeFromID(n.next(h))
+		}
+		s.stack[h] = n
+		s.rank[h] = r
+	}
+
+	if n = s.stack[0]; !s.multi && n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key) {
+		return n.next(0)
+	}
+
+	return 0
+}
+
+// rewind moves the stack back to the sentinel, the position before every key
+func (s *SkipList) rewind() {
+	for h := range s.stack {
+		s.stack[h] = s.sentinel
+		s.rank[h] = 0
+	}
+}
@@ -0,0 +1,125 @@
+package skiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSeekAppend(t *testing.T) {
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		if !sk.Insert(key) {
+			t.Fatal("Failed to insert value")
+		}
+
+		if last := sk.arena.keyOf(sk.stack[0]); string(last) != string(key) {
+			t.Fatalf("Expected the stack past %s got %s", key, last)
+		}
+	}
+
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if r := sk.Rank([]byte("0500")); r != 500 {
+		t.Fatalf("Expected rank 500 got %v", r)
+	}
+}
+
+func TestSeekMixed(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	sk := New()
+	model := map[int]bool{}
+
+	for i := 0; i < 5000; i++ {
+		// mostly increasing keys with some going back and some removals
+		k := i + rnd.Intn(20) - 5
+		if rnd.Intn(10) == 0 {
+			k = rnd.Intn(i + 1)
+		}
+		key := []byte(fmt.Sprintf("%05d", k))
+
+		if rnd.Intn(5) == 0 {
+			if sk.Remove(key) != model[k] {
+				t.Fatalf("Remove of %s disagrees with the model", key)
+			}
+			delete(model, k)
+			continue
+		}
+
+		if _, replaced, _ := sk.Put(key, key); replaced != model[k] {
+			t.Fatalf("Put of %s disagrees with the model", key)
+		}
+		model[k] = true
+	}
+
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []int
+	for k := range model {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	it := sk.NewIterator()
+	defer it.Close()
+
+	i := 0
+	for it.First(); it.Valid(); it.Next() {
+		if string(it.Key()) != fmt.Sprintf("%05d", keys[i]) {
+			t.Fatalf("Expected %05d got %s", keys[i], it.Key())
+		}
+		i++
+	}
+	if i != len(keys) || sk.Size() != uint(len(keys)) {
+		t.Fatalf("Expected %v keys got %v", len(keys), i)
+	}
+}
+
+func TestSeekMulti(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true, Tiebreak: Bytewise})
+	for i := 0; i < 100; i++ {
+		sk.Put([]byte(fmt.Sprintf("%03d", i/4)), []byte(fmt.Sprintf("%v", 3-i%4)))
+	}
+
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	found := sk.FindAll([]byte("007"))
+	if fmt.Sprintf("%s", found) != "[0 1 2 3]" {
+		t.Fatalf("Wrong entries %s", found)
+	}
+}
+
+func TestSeekRewind(t *testing.T) {
+	sk := newRangeList()
+	o := newRangeList()
+	o.Put([]byte("95"), nil)
+
+	if err := sk.Merge(o); err != nil {
+		t.Fatal(err)
+	}
+	// s keeps its finger past the entry linked last, o is rewound
+	if string(sk.arena.keyOf(sk.stack[0])) != "95" || o.stack[0] != o.sentinel {
+		t.Fatal("Merge should leave the stacks on valid positions")
+	}
+
+	sk.Put([]byte("99"), nil)
+	sk.Put([]byte("05"), nil)
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	checkRange(t, sk.Range(&Bounds{Lower: []byte("90")}), "90", "95", "99")
+}
+
+func BenchmarkAppend(b *testing.B) {
+	sk := New()
+	for i := 0; i < b.N; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%012d", i)))
+	}
+}
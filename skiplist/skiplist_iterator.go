@@ -0,0 +1,171 @@
+package skiplist
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// Iterator is a lazy bidirectional cursor over the SkipList, it holds the
+// NodeID of the current node so that scans can be stopped and resumed
+// without materializing the keys, moving backward costs a predecessor search
+type Iterator struct {
+	list       *SkipList
+	id         NodeID
+	prefix     []byte
+	snap       *Snapshot
+	tombstones bool
+	epoch      uint64
+	value      uint64
+}
+
+// NewIterator returns an unpositioned iterator over the SkipList, call one of
+// the First, Last or Seek methods before using it.
+//
+// Close must be called once done: the nodes removed while an iterator is open
+// are not reused until it is closed, and neither are the ones removed later
+// on since the reclamation can't move past it. An iterator dropped without
+// Close is closed by the garbage collector when it finalizes it, until then
+// every write removing nodes makes the arena grow
+func (s *SkipList) NewIterator() *Iterator {
+	it := &Iterator{list: s, epoch: s.arena.enter()}
+	runtime.SetFinalizer(it, (*Iterator).Close)
+
+	return it
+}
+
+// Close releases the iterator, it must not be used afterwards. Closing it
+// again does nothing
+func (it *Iterator) Close() {
+	if it.list == nil {
+		return
+	}
+
+	runtime.SetFinalizer(it, nil)
+	it.list.arena.exit(it.epoch)
+	it.list = nil
+}
+
+// Valid returns true when the iterator is positioned on a node
+func (it *Iterator) Valid() bool {
+	return it.id != 0 && it.id != it.list.head
+}
+
+// Key returns the key of the current node, the iterator must be valid. The
+// key points into the list and must not be modified, writing to it breaks
+// the order of the list
+func (it *Iterator) Key() []byte {
+	return it.list.arena.KeyFromID(it.id)
+}
+
+// Value returns the value of the current node, the iterator must be valid.
+// It is the value the node held when the iterator moved onto it, it points
+// into the list and must not be modified
+func (it *Iterator) Value() []byte {
+	return it.list.arena.data.value(it.value)
+}
+
+// First moves the iterator to the smallest key
+func (it *Iterator) First() {
+	if it.prefix != nil {
+		it.SeekGE(it.prefix)
+		return
+	}
+
+	it.next(it.list.sentinel)
+	it.skipForward()
+}
+
+// Last moves the iterator to the biggest key
+func (it *Iterator) Last() {
+	if succ := PrefixSuccessor(it.prefix); succ != nil {
+		it.SeekLT(succ)
+		return
+	}
+
+	it.id = it.list.findLast()
+	it.skipBackward()
+	it.clamp()
+}
+
+// SeekGE moves the iterator to the first key greater or equal than key
+func (it *Iterator) SeekGE(key []byte) {
+	it.next(it.list.arena.NodeFromID(it.list.findPrev(key)))
+	it.skipForward()
+	it.clamp()
+}
+
+// SeekLT moves the iterator to the last key smaller than key
+func (it *Iterator) SeekLT(key []byte) {
+	it.id = it.list.findPrev(key)
+	it.skipBackward()
+	it.clamp()
+}
+
+// Next moves the iterator to the following key, it becomes invalid once
+// the end of the list is reached
+func (it *Iterator) Next() {
+	if !it.Valid() {
+		return
+	}
+
+	it.next(it.list.arena.NodeFromID(it.id))
+	it.skipForward()
+	it.clamp()
+}
+
+// Prev moves the iterator to the preceding key, it becomes invalid once
+// the beginning of the list is reached
+func (it *Iterator) Prev() {
+	if !it.Valid() {
+		return
+	}
+
+	it.id = it.list.before(it.id)
+	it.skipBackward()
+	it.clamp()
+}
+
+// clamp invalidates the iterator when it moves past its prefix
+func (it *Iterator) clamp() {
+	if it.prefix != nil && it.Valid() && !bytes.HasPrefix(it.Key(), it.prefix) {
+		it.id = 0
+	}
+}
+
+// visible returns true when the current node holds a key that exists for
+// the iterator, either in the list or in its snapshot, or a tombstone the
+// iterator was asked for. It keeps the value the node held for Value, read
+// along with the deleted flag since writers change them one after the other
+func (it *Iterator) visible() (ok bool) {
+	if it.snap != nil {
+		it.value, ok = it.list.version(it.id, it.snap.seq, it.snap.now)
+		return ok
+	}
+
+	n := it.list.arena.NodeFromID(it.id)
+	it.value, ok = it.list.liveValue(n)
+	return ok || (it.tombstones && n.tombstone.Load())
+}
+
+// skipForward moves past the nodes that are not visible
+func (it *Iterator) skipForward() {
+	for it.Valid() && !it.visible() {
+		it.next(it.list.arena.NodeFromID(it.id))
+	}
+}
+
+// skipBackward moves back past the nodes that are not visible
+func (it *Iterator) skipBackward() {
+	for it.Valid() && !it.visible() {
+		it.id = it.list.before(it.id)
+	}
+}
+
+func (it *Iterator) next(n *Node) {
+	if !n.isNotNull(it.list.arena, 0) {
+		it.id = 0
+		return
+	}
+
+	it.id = n.next(0)
+}
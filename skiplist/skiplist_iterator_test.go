@@ -0,0 +1,95 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newIteratorList(t *testing.T) *SkipList {
+	sk := New()
+	for i := 0; i < 100; i++ {
+		if ok := sk.Insert([]byte(fmt.Sprintf("key%03d", i*2))); !ok {
+			t.Fatal("Failed to insert New value")
+		}
+	}
+
+	return sk
+}
+
+func TestIteratorForward(t *testing.T) {
+	sk := newIteratorList(t)
+	it := sk.NewIterator()
+	defer it.Close()
+
+	i := 0
+	for it.First(); it.Valid(); it.Next() {
+		if key := fmt.Sprintf("key%03d", i*2); string(it.Key()) != key {
+			t.Fatalf("Expected %v got %v", key, string(it.Key()))
+		}
+		i++
+	}
+
+	if i != 100 {
+		t.Fatalf("Expected 100 keys got %v", i)
+	}
+}
+
+func TestIteratorBackward(t *testing.T) {
+	sk := newIteratorList(t)
+	it := sk.NewIterator()
+	defer it.Close()
+
+	i := 99
+	for it.Last(); it.Valid(); it.Prev() {
+		if key := fmt.Sprintf("key%03d", i*2); string(it.Key()) != key {
+			t.Fatalf("Expected %v got %v", key, string(it.Key()))
+		}
+		i--
+	}
+
+	if i != -1 {
+		t.Fatalf("Expected 100 keys got %v", 99-i)
+	}
+}
+
+func TestIteratorSeek(t *testing.T) {
+	sk := newIteratorList(t)
+	it := sk.NewIterator()
+	defer it.Close()
+
+	it.SeekGE([]byte("key011"))
+	if !it.Valid() || string(it.Key()) != "key012" {
+		t.Fatal("SeekGE should land on the next key")
+	}
+
+	it.SeekGE([]byte("key012"))
+	if !it.Valid() || string(it.Key()) != "key012" {
+		t.Fatal("SeekGE should land on the same key")
+	}
+
+	it.SeekLT([]byte("key012"))
+	if !it.Valid() || string(it.Key()) != "key010" {
+		t.Fatal("SeekLT should land on the previous key")
+	}
+
+	it.SeekLT([]byte("key000"))
+	if it.Valid() {
+		t.Fatal("SeekLT before the first key should be invalid")
+	}
+
+	it.SeekGE([]byte("key999"))
+	if it.Valid() {
+		t.Fatal("SeekGE after the last key should be invalid")
+	}
+}
+
+func TestIteratorEmpty(t *testing.T) {
+	it := New().NewIterator()
+	defer it.Close()
+	if it.First(); it.Valid() {
+		t.Fatal("Iterator over an empty list should be invalid")
+	}
+	if it.Last(); it.Valid() {
+		t.Fatal("Iterator over an empty list should be invalid")
+	}
+}
@@ -0,0 +1,97 @@
+package skiplist
+
+import "bytes"
+
+// In multi mode, set with Conf.Multi, every Insert or Put of a key adds a new
+// entry instead of failing or replacing the value, so that a key can map to
+// many values like the postings of a term. The entries of a key are kept in
+// insertion order, or ordered by value when Conf.Tiebreak is set, and the
+// iterators yield every one of them. Find, Get and Remove act on the first
+// entry of a key, FindAll and RemoveOne on all of them
+
+// FindAll returns the values of every entry of key in order, they point into
+// the list and must not be modified
+func (s *SkipList) FindAll(key []byte) [][]byte {
+	var found [][]byte
+
+	n := s.arena.NodeFromID(s.findPrev(key))
+	for n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key) {
+		n = s.arena.NodeFromID(n.next(0))
+		if ref, ok := s.liveValue(n); ok {
+			found = append(found, s.arena.data.value(ref))
+		}
+	}
+
+	return found
+}
+
+// RemoveOne removes the first entry of key holding value and returns true, or
+// false when there is none. It walks the entries of the key to find it
+func (s *SkipList) RemoveOne(key []byte, value []byte) bool {
+	return s.removeFirst(key, func(v []byte) bool {
+		return bytes.Equal(v, value)
+	})
+}
+
+// precedes returns true when node n goes before a new entry of key and value
+// in multi mode, the new entry follows the ones it ties with
+func (s *SkipList) precedes(n *Node, key []byte, value []byte) bool {
+	c := s.cmp.Compare(s.arena.keyOf(n), key)
+	if c == 0 && s.tiebreak != nil {
+		c = s.tiebreak.Compare(s.arena.data.value(n.value.Load()), value)
+	}
+
+	return c <= 0
+}
+
+// byValue returns true when the values order the entries of a key, deleted
+// nodes then keep their value so that they stay in order
+func (s *SkipList) byValue() bool {
+	return s.multi && s.tiebreak != nil
+}
+
+// searchAfter fills the stack like search does with the last node preceding
+// a new entry of key and value on each level
+func (s *SkipList) searchAfter(key []byte, value []byte) {
+	n, r := s.sentinel, 0
+	for h := s.Height(); h >= 0; h-- {
+		for n.isNotNull(s.arena, h) && s.precedes(s.arena.NodeFromID(n.next(h)), key, value) {
+			r += int(n.Span[h])
+			n = s.arena.NodeFromID(n.next(h))
+		}
+		s.stack[h] = n
+		s.rank[h] = r
+	}
+}
+
+// locate fills the stack so that node id can be unlinked, the entries
+// sharing its key are walked on level 0 and become the last node of each of
+// their levels
+func (s *SkipList) locate(id NodeID) {
+	s.search(s.arena.KeyFromID(id))
+
+	for n := s.stack[0]; n.isNotNull(s.arena, 0) && n.next(0) != id; {
+		n = s.arena.NodeFromID(n.next(0))
+		r := s.rank[0] + int(n.weight())
+		for h := 0; h <= n.height(); h++ {
+			s.stack[h], s.rank[h] = n, r
+		}
+	}
+}
+
+// before returns the node preceding id on level 0, the entries sharing its
+// key are walked from the predecessor of the key
+func (s *SkipList) before(id NodeID) NodeID {
+	key := s.arena.KeyFromID(id)
+
+	prev := s.findPrev(key)
+	for s.multi {
+		n := s.arena.NodeFromID(prev)
+		if !n.isNotNull(s.arena, 0) || n.next(0) == id || !s.eq(s.arena.KeyFromID(n.next(0)), key) {
+			break
+		}
+		prev = n.next(0)
+	}
+
+	return prev
+}
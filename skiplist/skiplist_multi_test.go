@@ -0,0 +1,201 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func checkValues(t *testing.T, found [][]byte, expected ...string) {
+	t.Helper()
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %v values got %q", expected, found)
+	}
+	for i := range found {
+		if string(found[i]) != expected[i] {
+			t.Fatalf("Expected %v got %q", expected, found)
+		}
+	}
+}
+
+func TestMultiInsertionOrder(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true})
+	sk.Put([]byte("b"), []byte("3"))
+	sk.Put([]byte("a"), []byte("1"))
+	sk.Put([]byte("b"), []byte("1"))
+	sk.Put([]byte("c"), []byte("1"))
+	sk.Put([]byte("b"), []byte("2"))
+
+	if sk.Size() != 5 {
+		t.Fatalf("Expected 5 entries got %v", sk.Size())
+	}
+
+	checkValues(t, sk.FindAll([]byte("b")), "3", "1", "2")
+	checkValues(t, sk.FindAll([]byte("d")))
+
+	if value, ok := sk.Get([]byte("b")); !ok || string(value) != "3" {
+		t.Fatalf("Get should return the first entry got %v", string(value))
+	}
+
+	if !sk.Insert([]byte("a")) {
+		t.Fatal("Insert should add a duplicate in multi mode")
+	}
+
+	var got []string
+	it := sk.NewIterator()
+	for it.Last(); it.Valid(); it.Prev() {
+		got = append(got, string(it.Key())+string(it.Value()))
+	}
+	it.Close()
+
+	if fmt.Sprint(got) != "[c1 b2 b1 b3 a a1]" {
+		t.Fatalf("Wrong backward iteration %v", got)
+	}
+}
+
+func TestMultiTiebreak(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true, Tiebreak: Bytewise})
+	for _, v := range []string{"5", "1", "3", "2", "4", "3"} {
+		sk.Put([]byte("term"), []byte(v))
+	}
+
+	checkValues(t, sk.FindAll([]byte("term")), "1", "2", "3", "3", "4", "5")
+
+	if !sk.RemoveOne([]byte("term"), []byte("3")) || !sk.RemoveOne([]byte("term"), []byte("5")) {
+		t.Fatal("RemoveOne should remove existing entries")
+	}
+
+	if sk.RemoveOne([]byte("term"), []byte("6")) {
+		t.Fatal("RemoveOne should not remove a missing entry")
+	}
+
+	checkValues(t, sk.FindAll([]byte("term")), "1", "2", "3", "4")
+
+	for i := 0; i < int(sk.Size()); i++ {
+		if _, ok := sk.Select(i); !ok {
+			t.Fatalf("Missing entry %v", i)
+		}
+	}
+
+	if sk.CountRange([]byte("term"), []byte("term")) != 4 {
+		t.Fatal("Every entry of the key should be counted")
+	}
+}
+
+func TestMultiRemove(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true})
+	for i := 0; i < 1000; i++ {
+		sk.Put([]byte(fmt.Sprint(i%10)), []byte(fmt.Sprint(i)))
+	}
+
+	for i := 0; i < 1000; i += 2 {
+		if !sk.RemoveOne([]byte(fmt.Sprint(i%10)), []byte(fmt.Sprint(i))) {
+			t.Fatalf("Failed to remove %v", i)
+		}
+	}
+
+	if sk.Size() != 500 {
+		t.Fatalf("Expected 500 entries got %v", sk.Size())
+	}
+
+	if found := sk.FindAll([]byte("3")); len(found) != 100 || string(found[0]) != "3" || string(found[99]) != "993" {
+		t.Fatalf("Wrong entries for 3 %q", found)
+	}
+
+	if !sk.Remove([]byte("3")) {
+		t.Fatal("Remove should remove the first entry")
+	}
+
+	if value, _ := sk.Get([]byte("3")); string(value) != "13" {
+		t.Fatalf("Expected 13 got %v", string(value))
+	}
+
+	if removed := sk.RemoveRange([]byte("1"), []byte("3")); removed != 199 {
+		t.Fatalf("Expected 199 entries removed got %v", removed)
+	}
+}
+
+func TestMultiScanExclusive(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true})
+	for _, kv := range []string{"a0", "b1", "b2", "c3"} {
+		sk.Put([]byte(kv[:1]), []byte(kv[1:]))
+	}
+
+	var found [][]byte
+	sk.Scan(&Bounds{Lower: []byte("b"), LowerExclusive: true}, func(key []byte, value []byte) bool {
+		found = append(found, append(append([]byte{}, key...), value...))
+		return true
+	})
+	checkValues(t, found, "c3")
+
+	found = found[:0]
+	sk.Scan(&Bounds{Lower: []byte("a"), LowerExclusive: true, Upper: []byte("b")}, func(key []byte, value []byte) bool {
+		found = append(found, append(append([]byte{}, key...), value...))
+		return true
+	})
+	checkValues(t, found, "b1", "b2")
+}
+
+func TestMultiSnapshot(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true})
+	sk.Put([]byte("k"), []byte("1"))
+	sk.Put([]byte("k"), []byte("2"))
+
+	sn := sk.Snapshot()
+	sk.RemoveOne([]byte("k"), []byte("1"))
+	sk.Put([]byte("k"), []byte("3"))
+
+	checkValues(t, sk.FindAll([]byte("k")), "2", "3")
+	checkValues(t, sn.Range(&Bounds{}), "k", "k")
+
+	if value, _ := sk.Get([]byte("k")); string(value) != "2" {
+		t.Fatalf("Expected 2 got %v", string(value))
+	}
+
+	sn.Release()
+	if sk.nodeCount.Load() != 2 {
+		t.Fatalf("Expected 2 nodes after release got %v", sk.nodeCount.Load())
+	}
+	checkValues(t, sk.FindAll([]byte("k")), "2", "3")
+}
+
+func TestMultiSnapshotTiebreak(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true, Tiebreak: Bytewise})
+	sk.Put([]byte("k"), []byte("a"))
+	sk.Put([]byte("k"), []byte("b"))
+
+	// the deleted entry kept for the snapshot stays ordered by its value
+	sn := sk.Snapshot()
+	sk.RemoveOne([]byte("k"), []byte("b"))
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	sk.Put([]byte("k"), []byte("c"))
+	sk.Put([]byte("k"), []byte("0"))
+	sk.Delete([]byte("k"))
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	sn.Release()
+	sk.Put([]byte("k"), []byte("b"))
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, sk.FindAll([]byte("k")), "b")
+}
+
+func TestMultiBulkLoad(t *testing.T) {
+	keys := [][]byte{[]byte("a"), []byte("a"), []byte("b")}
+	values := [][]byte{[]byte("2"), []byte("1"), []byte("1")}
+
+	sk, err := BulkLoad(SliceSource(keys, values), &Conf{Multi: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, sk.FindAll([]byte("a")), "2", "1")
+
+	if _, err := BulkLoad(SliceSource(keys, values), &Conf{Multi: true, Tiebreak: Bytewise}); err != ErrUnsorted {
+		t.Fatalf("Expected ErrUnsorted got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package skiplist
+
+// Floor returns the entry with the biggest key smaller or equal than key, ok
+// is false when there is none. The key and the value point into the list and
+// must not be modified
+func (s *SkipList) Floor(key []byte) (found []byte, value []byte, ok bool) {
+	return s.nearest(func(it *Iterator) {
+		it.SeekGE(key)
+		if !it.Valid() || !s.eq(it.Key(), key) {
+			it.SeekLT(key)
+		}
+	})
+}
+
+// Ceiling returns the entry with the smallest key bigger or equal than key,
+// ok is false when there is none. The key and the value point into the list
+// and must not be modified
+func (s *SkipList) Ceiling(key []byte) (found []byte, value []byte, ok bool) {
+	return s.nearest(func(it *Iterator) {
+		it.SeekGE(key)
+	})
+}
+
+// Lower returns the entry with the biggest key strictly smaller than key, ok
+// is false when there is none. The key and the value point into the list and
+// must not be modified
+func (s *SkipList) Lower(key []byte) (found []byte, value []byte, ok bool) {
+	return s.nearest(func(it *Iterator) {
+		it.SeekLT(key)
+	})
+}
+
+// Higher returns the entry with the smallest key strictly bigger than key, ok
+// is false when there is none. The key and the value point into the list and
+// must not be modified
+func (s *SkipList) Higher(key []byte) (found []byte, value []byte, ok bool) {
+	return s.nearest(func(it *Iterator) {
+		for it.SeekGE(key); it.Valid() && s.eq(it.Key(), key); {
+			it.Next()
+		}
+	})
+}
+
+// Min returns the entry with the smallest key, ok is false when the list is
+// empty. The key and the value point into the list and must not be modified
+func (s *SkipList) Min() (key []byte, value []byte, ok bool) {
+	return s.nearest((*Iterator).First)
+}
+
+// Max returns the entry with the biggest key, ok is false when the list is
+// empty. The key and the value point into the list and must not be modified
+func (s *SkipList) Max() (key []byte, value []byte, ok bool) {
+	return s.nearest((*Iterator).Last)
+}
+
+// PopMin removes and returns the entry with the smallest key, so that the
+// list can serve as a priority queue, ok is false when the list is empty.
+// The key and the value point into the list and must not be modified
+func (s *SkipList) PopMin() (key []byte, value []byte, ok bool) {
+	return s.pop((*Iterator).First)
+}
+
+// PopMax removes and returns the entry with the biggest key, ok is false when
+// the list is empty. The key and the value point into the list and must not be
+// modified
+func (s *SkipList) PopMax() (key []byte, value []byte, ok bool) {
+	return s.pop((*Iterator).Last)
+}
+
+// nearest returns the entry an iterator lands on after seek
+func (s *SkipList) nearest(seek func(it *Iterator)) (key []byte, value []byte, ok bool) {
+	it := s.NewIterator()
+	defer it.Close()
+
+	if seek(it); !it.Valid() {
+		return nil, nil, false
+	}
+
+	return it.Key(), it.Value(), true
+}
+
+// pop removes the entry an iterator lands on after seek, in multi mode it is
+// that very entry and not the first one of its key
+func (s *SkipList) pop(seek func(it *Iterator)) (key []byte, value []byte, ok bool) {
+	it := s.NewIterator()
+	if seek(it); !it.Valid() {
+		it.Close()
+		return nil, nil, false
+	}
+
+	id := it.id
+	key, value = it.Key(), it.Value()
+	it.Close()
+
+	s.locate(id)
+	return key, value, s.remove(id)
+}
@@ -0,0 +1,98 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func checkEntry(t *testing.T, key []byte, ok bool, expected string) {
+	t.Helper()
+	switch {
+	case expected == "" && ok:
+		t.Fatalf("Expected no entry got %v", string(key))
+	case expected != "" && (!ok || string(key) != expected):
+		t.Fatalf("Expected %v got %v", expected, string(key))
+	}
+}
+
+func TestNearest(t *testing.T) {
+	sk := newRangeList()
+
+	key, _, ok := sk.Floor([]byte("35"))
+	checkEntry(t, key, ok, "30")
+	key, value, ok := sk.Floor([]byte("30"))
+	checkEntry(t, key, ok, "30")
+	if string(value) != "3" {
+		t.Fatalf("Expected value 3 got %v", string(value))
+	}
+	key, _, ok = sk.Floor([]byte("0"))
+	checkEntry(t, key, ok, "")
+
+	key, _, ok = sk.Ceiling([]byte("35"))
+	checkEntry(t, key, ok, "40")
+	key, _, ok = sk.Ceiling([]byte("40"))
+	checkEntry(t, key, ok, "40")
+	key, _, ok = sk.Ceiling([]byte("95"))
+	checkEntry(t, key, ok, "")
+
+	key, _, ok = sk.Lower([]byte("40"))
+	checkEntry(t, key, ok, "30")
+	key, _, ok = sk.Lower([]byte("00"))
+	checkEntry(t, key, ok, "")
+
+	key, _, ok = sk.Higher([]byte("40"))
+	checkEntry(t, key, ok, "50")
+	key, _, ok = sk.Higher([]byte("90"))
+	checkEntry(t, key, ok, "")
+
+	key, _, ok = sk.Min()
+	checkEntry(t, key, ok, "00")
+	key, _, ok = sk.Max()
+	checkEntry(t, key, ok, "90")
+
+	sk.Delete([]byte("30"))
+	key, _, ok = sk.Floor([]byte("30"))
+	checkEntry(t, key, ok, "20")
+
+	key, _, ok = New().Min()
+	checkEntry(t, key, ok, "")
+}
+
+func TestPop(t *testing.T) {
+	sk := New()
+	for _, i := range []int{5, 3, 9, 1, 7} {
+		sk.Put([]byte(fmt.Sprint(i)), []byte(fmt.Sprint(i*10)))
+	}
+
+	var got []string
+	for sk.Size() > 1 {
+		key, value, ok := sk.PopMin()
+		if !ok {
+			t.Fatal("PopMin should succeed")
+		}
+		got = append(got, string(key)+"="+string(value))
+	}
+
+	if fmt.Sprint(got) != "[1=10 3=30 5=50 7=70]" {
+		t.Fatalf("Wrong pop order %v", got)
+	}
+
+	key, _, ok := sk.PopMax()
+	checkEntry(t, key, ok, "9")
+
+	key, _, ok = sk.PopMin()
+	checkEntry(t, key, ok, "")
+}
+
+func TestPopMulti(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true})
+	sk.Put([]byte("a"), []byte("1"))
+	sk.Put([]byte("b"), []byte("1"))
+	sk.Put([]byte("b"), []byte("2"))
+
+	if key, value, ok := sk.PopMax(); !ok || string(key) != "b" || string(value) != "2" {
+		t.Fatalf("Expected b=2 got %v=%v", string(key), string(value))
+	}
+
+	checkValues(t, sk.FindAll([]byte("b")), "1")
+}
@@ -0,0 +1,6 @@
+//go:build !skiplistdebug
+
+package skiplist
+
+// debug is set by the skiplistdebug build tag
+const debug = false
@@ -0,0 +1,202 @@
+package skiplist
+
+// Bounds describes a range query, a nil bound leaves the range open on that
+// side and bounds are inclusive unless told otherwise, so the zero value
+// selects every key
+type Bounds struct {
+	Lower          []byte
+	Upper          []byte
+	LowerExclusive bool
+	UpperExclusive bool
+	Limit          int // Limit caps the number of keys, no limit when 0
+}
+
+// Scan visits in order the keys within b until visit returns false, it
+// returns the number of keys visited. Unlike RangeFind the bounds don't need
+// to be present in the list. The keys and values given to visit point into
+// the list and must not be modified
+func (s *SkipList) Scan(b *Bounds, visit func(key []byte, value []byte) bool) int {
+	return scan(s.NewIterator(), b, visit)
+}
+
+// scan runs a Scan over it and closes it
+func scan(it *Iterator, b *Bounds, visit func(key []byte, value []byte) bool) int {
+	defer it.Close()
+
+	it.seekLower(b)
+
+	count := 0
+	for ; it.Valid() && b.below(it.list.cmp, it.Key()); it.Next() {
+		if b.Limit > 0 && count == b.Limit {
+			break
+		}
+
+		count++
+		if !visit(it.Key(), it.Value()) {
+			break
+		}
+	}
+
+	return count
+}
+
+// Range returns the keys within b, they point into the list and must not be
+// modified
+func (s *SkipList) Range(b *Bounds) [][]byte {
+	return keys(s.NewIterator(), b)
+}
+
+// keys collects the keys of a scan over it
+func keys(it *Iterator, b *Bounds) [][]byte {
+	var found [][]byte
+	scan(it, b, func(key []byte, value []byte) bool {
+		found = append(found, key)
+		return true
+	})
+
+	return found
+}
+
+// RemoveRange removes the keys between start and end, both included, and
+// returns the number of keys removed. The run of nodes is cut out of every
+// level in a single pass and the nodes go back to the arena. While snapshots
+// are open the keys are removed one by one so that the snapshots keep them
+func (s *SkipList) RemoveRange(start []byte, end []byte) int {
+	if s.cmp.Compare(start, end) > 0 {
+		return 0
+	}
+
+	if len(s.snapshots) > 0 {
+		var found [][]byte
+		s.Scan(&Bounds{Lower: start, Upper: end}, func(key []byte, value []byte) bool {
+			found = append(found, key)
+			return true
+		})
+
+		for _, key := range found {
+			s.Remove(key)
+		}
+
+		return len(found)
+	}
+
+	s.search(start)
+
+	var run []NodeID
+	live := 0
+	for n := s.stack[0]; n.isNotNull(s.arena, 0) && s.cmp.Compare(s.arena.KeyFromID(n.next(0)), end) <= 0; {
+		run = append(run, n.next(0))
+		n = s.arena.NodeFromID(n.next(0))
+		live += int(n.weight())
+	}
+
+	if len(run) == 0 {
+		return 0
+	}
+
+	// every level skips from the node before start to the first node after
+	// end, links are unpublished top down like unlink does
+	for h := s.Height(); h >= 0; h-- {
+		prev, last, r := s.stack[h], s.stack[h], s.rank[h]
+		for last.isNotNull(s.arena, h) && s.cmp.Compare(s.arena.KeyFromID(last.next(h)), end) <= 0 {
+			r += int(last.Span[h])
+			last = s.arena.NodeFromID(last.next(h))
+		}
+
+		if last != prev {
+			prev.setNext(h, last.next(h))
+		}
+		prev.Span[h] = uint32(r + int(last.Span[h]) - live - s.rank[h])
+	}
+
+	removed := 0
+	for _, id := range run {
+		node := s.arena.NodeFromID(id)
+		if node.deleted.Load() {
+			s.deleted.Add(-1)
+		} else if !s.expired(node) {
+			removed++
+		}
+
+		for ver := NodeID(node.older.Load()); ver != 0; {
+			older := NodeID(s.arena.NodeFromID(ver).older.Load())
+			s.arena.retire(ver)
+			ver = older
+		}
+		delete(s.versioned, id)
+		s.arena.retire(id)
+	}
+
+	s.shrink()
+	s.nodeCount.Add(^uint64(len(run) - 1))
+	s.verify()
+
+	return removed
+}
+
+// seekLower moves the iterator to the first key satisfying the lower bound
+func (it *Iterator) seekLower(b *Bounds) {
+	if b.Lower == nil {
+		it.First()
+		return
+	}
+
+	it.SeekGE(b.Lower)
+	for b.LowerExclusive && it.Valid() && it.list.eq(it.Key(), b.Lower) {
+		it.Next()
+	}
+}
+
+// below returns true when key satisfies the upper bound
+func (b *Bounds) below(cmp Comparator, key []byte) bool {
+	if b.Upper == nil {
+		return true
+	}
+
+	c := cmp.Compare(key, b.Upper)
+	return c < 0 || (c == 0 && !b.UpperExclusive)
+}
+
+// PrefixIterator returns an iterator restricted to the keys starting with
+// prefix, First and Last land on the first and last of them and the iterator
+// becomes invalid as soon as it moves past them. Prefix scans assume an
+// ordering where keys sharing a prefix are contiguous, like Bytewise does
+func (s *SkipList) PrefixIterator(prefix []byte) *Iterator {
+	it := s.NewIterator()
+	it.prefix = append([]byte{}, prefix...)
+
+	return it
+}
+
+// PrefixScan visits in order the keys starting with prefix until visit
+// returns false, it returns the number of keys visited. The keys and values
+// given to visit point into the list and must not be modified
+func (s *SkipList) PrefixScan(prefix []byte, visit func(key []byte, value []byte) bool) int {
+	it := s.PrefixIterator(prefix)
+	defer it.Close()
+
+	count := 0
+	for it.First(); it.Valid(); it.Next() {
+		count++
+		if !visit(it.Key(), it.Value()) {
+			break
+		}
+	}
+
+	return count
+}
+
+// PrefixSuccessor returns the smallest key bigger than every key starting
+// with prefix, to be used as an exclusive upper bound. It returns nil when
+// there is none, that is when prefix is empty or made of 0xff bytes only
+func PrefixSuccessor(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			succ := append([]byte{}, prefix[:i+1]...)
+			succ[i]++
+			return succ
+		}
+	}
+
+	return nil
+}
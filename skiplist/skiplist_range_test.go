@@ -0,0 +1,204 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newRangeList() *SkipList {
+	sk := New()
+	for i := 0; i < 10; i++ {
+		sk.Put([]byte(fmt.Sprintf("%02d", i*10)), []byte(fmt.Sprintf("%v", i)))
+	}
+
+	return sk
+}
+
+func checkRange(t *testing.T, found [][]byte, expected ...string) {
+	t.Helper()
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %v keys got %v", len(expected), len(found))
+	}
+	for i := range found {
+		if string(found[i]) != expected[i] {
+			t.Fatalf("Expected %v got %v", expected[i], string(found[i]))
+		}
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	sk := newRangeList()
+
+	checkRange(t, sk.Range(&Bounds{Lower: []byte("15"), Upper: []byte("45")}), "20", "30", "40")
+	checkRange(t, sk.Range(&Bounds{Lower: []byte("20"), Upper: []byte("40")}), "20", "30", "40")
+	checkRange(t, sk.Range(&Bounds{
+		Lower:          []byte("20"),
+		Upper:          []byte("40"),
+		LowerExclusive: true,
+		UpperExclusive: true,
+	}), "30")
+	checkRange(t, sk.Range(&Bounds{Upper: []byte("20")}), "00", "10", "20")
+	checkRange(t, sk.Range(&Bounds{Lower: []byte("75")}), "80", "90")
+	checkRange(t, sk.Range(&Bounds{Lower: []byte("95")}))
+	checkRange(t, sk.Range(&Bounds{Lower: []byte("10"), Limit: 2}), "10", "20")
+
+	if found := sk.Range(&Bounds{}); len(found) != 10 {
+		t.Fatalf("Expected 10 keys got %v", len(found))
+	}
+}
+
+func TestScanVisitor(t *testing.T) {
+	sk := newRangeList()
+
+	var values []string
+	n := sk.Scan(&Bounds{Lower: []byte("33")}, func(key []byte, value []byte) bool {
+		values = append(values, string(value))
+		return len(values) < 3
+	})
+
+	if n != 3 || len(values) != 3 || values[0] != "4" {
+		t.Fatalf("Visitor did not stop early %v %v", n, values)
+	}
+}
+
+func TestPrefixScan(t *testing.T) {
+	sk := New()
+	for _, k := range []string{"cpu\x00a", "cpu\x00b", "cpu\x01", "cpuz", "mem\x00a", "c"} {
+		sk.Insert([]byte(k))
+	}
+
+	var found [][]byte
+	n := sk.PrefixScan([]byte("cpu\x00"), func(key []byte, value []byte) bool {
+		found = append(found, key)
+		return true
+	})
+	if n != 2 {
+		t.Fatalf("Expected 2 keys got %v", n)
+	}
+	checkRange(t, found, "cpu\x00a", "cpu\x00b")
+
+	it := sk.PrefixIterator([]byte("cpu"))
+	defer it.Close()
+
+	if it.Last(); !it.Valid() || string(it.Key()) != "cpuz" {
+		t.Fatal("Last should land on the last key with the prefix")
+	}
+	if it.Next(); it.Valid() {
+		t.Fatal("Iterator should stop past the prefix")
+	}
+	if it.First(); !it.Valid() || string(it.Key()) != "cpu\x00a" {
+		t.Fatal("First should land on the first key with the prefix")
+	}
+	if it.Prev(); it.Valid() {
+		t.Fatal("Iterator should stop before the prefix")
+	}
+}
+
+func TestPrefixSuccessor(t *testing.T) {
+	cases := map[string]string{
+		"abc":        "abd",
+		"ab\xff":     "ac",
+		"a\xff\xff":  "b",
+		"\xff\xff":   "",
+		"":           "",
+		"metric\x00": "metric\x01",
+	}
+
+	for prefix, expected := range cases {
+		if succ := PrefixSuccessor([]byte(prefix)); string(succ) != expected {
+			t.Fatalf("Successor of %q should be %q got %q", prefix, expected, succ)
+		}
+	}
+
+	sk := New()
+	for _, k := range []string{"ab", "ab\xff", "ab\xff\x01", "ac"} {
+		sk.Insert([]byte(k))
+	}
+	checkRange(t, sk.Range(&Bounds{
+		Lower:          []byte("ab\xff"),
+		Upper:          PrefixSuccessor([]byte("ab\xff")),
+		UpperExclusive: true,
+	}), "ab\xff", "ab\xff\x01")
+}
+
+func TestRemoveRange(t *testing.T) {
+	sk := newRangeList()
+
+	if removed := sk.RemoveRange([]byte("15"), []byte("40")); removed != 3 {
+		t.Fatalf("Expected 3 keys removed got %v", removed)
+	}
+	checkRange(t, sk.Range(&Bounds{}), "00", "10", "50", "60", "70", "80", "90")
+
+	if sk.Size() != 7 {
+		t.Fatalf("Expected 7 keys got %v", sk.Size())
+	}
+
+	for i, key := range sk.Range(&Bounds{}) {
+		if sk.Rank(key) != i {
+			t.Fatalf("Wrong rank for %v", string(key))
+		}
+	}
+
+	if removed := sk.RemoveRange([]byte("91"), []byte("99")); removed != 0 {
+		t.Fatalf("Expected no key removed got %v", removed)
+	}
+
+	if removed := sk.RemoveRange([]byte("90"), []byte("00")); removed != 0 {
+		t.Fatalf("Empty range should remove nothing got %v", removed)
+	}
+
+	if removed := sk.RemoveRange([]byte("00"), []byte("90")); removed != 7 {
+		t.Fatalf("Expected 7 keys removed got %v", removed)
+	}
+
+	if sk.Size() != 0 || sk.Height() != 1 || !sk.Insert([]byte("50")) {
+		t.Fatal("Emptied list should be usable")
+	}
+}
+
+func TestRemoveRangeReuse(t *testing.T) {
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	if removed := sk.RemoveRange([]byte("0100"), []byte("0899")); removed != 800 {
+		t.Fatalf("Expected 800 keys removed got %v", removed)
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		if sk.Find(key) != (i < 100 || i >= 900) {
+			t.Fatalf("Wrong presence of %v", string(key))
+		}
+	}
+
+	if free := freeNodes(sk.arena); free != 800 {
+		t.Fatalf("Expected 800 free nodes got %v", free)
+	}
+
+	for i := 0; i < 800; i++ {
+		sk.Insert([]byte(fmt.Sprintf("a%04d", i)))
+	}
+
+	if key, ok := sk.Select(150); !ok || string(key) != "0950" {
+		t.Fatalf("Wrong key at 150 got %v", string(key))
+	}
+}
+
+func TestRemoveRangeSnapshot(t *testing.T) {
+	sk := newRangeList()
+	sn := sk.Snapshot()
+
+	if removed := sk.RemoveRange([]byte("20"), []byte("50")); removed != 4 {
+		t.Fatalf("Expected 4 keys removed got %v", removed)
+	}
+
+	checkRange(t, sk.Range(&Bounds{Upper: []byte("60")}), "00", "10", "60")
+	checkRange(t, sn.Range(&Bounds{Upper: []byte("60")}), "00", "10", "20", "30", "40", "50", "60")
+
+	sn.Release()
+	if sk.nodeCount.Load() != 6 {
+		t.Fatalf("Expected 6 nodes after release got %v", sk.nodeCount.Load())
+	}
+}
@@ -0,0 +1,59 @@
+package skiplist
+
+// Rank returns the number of keys smaller than key, it follows the spans of
+// the links walked by the search so it costs O(log n). Rank, Select and
+// CountRange count the keys Size counts, the tombstones and the deleted keys
+// kept for snapshots are left out while the expired ones count until they
+// are swept
+func (s *SkipList) Rank(key []byte) int {
+	return s.rankOf(key, false)
+}
+
+// rankOf returns the number of keys smaller than key, or smaller or equal when
+// inclusive is set
+func (s *SkipList) rankOf(key []byte, inclusive bool) int {
+	limit := 0
+	if inclusive {
+		limit = 1
+	}
+
+	n, r := s.sentinel, 0
+	for h := s.Height(); h >= 0; h-- {
+		for n.isNotNull(s.arena, h) && s.cmp.Compare(s.arena.KeyFromID(n.next(h)), key) < limit {
+			r += int(n.Span[h])
+			n = s.arena.NodeFromID(n.next(h))
+		}
+	}
+
+	return r
+}
+
+// Select returns the k-th smallest key counting from zero, and false when k
+// is out of range. The key points into the list and must not be modified
+func (s *SkipList) Select(k int) ([]byte, bool) {
+	if k < 0 || k >= int(s.Size()) {
+		return nil, false
+	}
+
+	// deleted nodes share the rank of the key before them, the walk stops
+	// before the key of rank k+1 and the key is the following node
+	n, r := s.sentinel, 0
+	for h := s.Height(); h >= 0; h-- {
+		for n.isNotNull(s.arena, h) && r+int(n.Span[h]) <= k {
+			r += int(n.Span[h])
+			n = s.arena.NodeFromID(n.next(h))
+		}
+	}
+
+	return s.arena.KeyFromID(n.next(0)), true
+}
+
+// CountRange returns the number of keys between start and end, both
+// included, without walking them
+func (s *SkipList) CountRange(start []byte, end []byte) int {
+	if count := s.rankOf(end, true) - s.Rank(start); count > 0 {
+		return count
+	}
+
+	return 0
+}
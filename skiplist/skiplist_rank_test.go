@@ -0,0 +1,204 @@
+package skiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestRankSelect(t *testing.T) {
+	sk := NewWithConf(&Conf{Source: rand.NewSource(3)})
+	perm := rand.New(rand.NewSource(5)).Perm(1000)
+	for _, i := range perm {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i*2)))
+	}
+
+	for i := 0; i < 1000; i++ {
+		if r := sk.Rank([]byte(fmt.Sprintf("%04d", i*2))); r != i {
+			t.Fatalf("Rank of %v should be %v got %v", i*2, i, r)
+		}
+		if r := sk.Rank([]byte(fmt.Sprintf("%04d", i*2+1))); r != i+1 {
+			t.Fatalf("Rank of %v should be %v got %v", i*2+1, i+1, r)
+		}
+
+		key, ok := sk.Select(i)
+		if !ok || string(key) != fmt.Sprintf("%04d", i*2) {
+			t.Fatalf("Select %v got %v", i, string(key))
+		}
+	}
+
+	if _, ok := sk.Select(1000); ok {
+		t.Fatal("Select out of range should fail")
+	}
+}
+
+func TestRankAfterRemove(t *testing.T) {
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+	for i := 0; i < 1000; i += 3 {
+		sk.Remove([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	i := 0
+	it := sk.NewIterator()
+	defer it.Close()
+	for it.First(); it.Valid(); it.Next() {
+		if r := sk.Rank(it.Key()); r != i {
+			t.Fatalf("Rank of %v should be %v got %v", string(it.Key()), i, r)
+		}
+		if key, _ := sk.Select(i); string(key) != string(it.Key()) {
+			t.Fatalf("Select %v got %v", i, string(key))
+		}
+		i++
+	}
+}
+
+func TestCountRange(t *testing.T) {
+	sk := New()
+	for i := 0; i < 100; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%03d", i*10)))
+	}
+
+	cases := []struct {
+		start, end string
+		count      int
+	}{
+		{"000", "990", 100},
+		{"100", "200", 11},
+		{"101", "199", 9},
+		{"500", "400", 0},
+		{"991", "999", 0},
+	}
+
+	for _, c := range cases {
+		if n := sk.CountRange([]byte(c.start), []byte(c.end)); n != c.count {
+			t.Fatalf("Count between %v and %v should be %v got %v", c.start, c.end, c.count, n)
+		}
+	}
+}
+
+func TestRankDeleted(t *testing.T) {
+	sk := New()
+	for _, k := range []string{"a", "b", "c"} {
+		sk.Insert([]byte(k))
+	}
+	if err := sk.Delete([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if key, ok := sk.Select(1); !ok || string(key) != "c" {
+		t.Fatalf("Select 1 should be c got %s", key)
+	}
+	if _, ok := sk.Select(2); ok || sk.Size() != 2 {
+		t.Fatal("Select past the size should fail")
+	}
+	if n := sk.CountRange([]byte("a"), []byte("c")); n != 2 {
+		t.Fatalf("Expected 2 keys got %v", n)
+	}
+	if r := sk.Rank([]byte("c")); r != 1 {
+		t.Fatalf("Expected rank 1 got %v", r)
+	}
+
+	// a tombstone written for a missing key counts for nothing either
+	sk.Delete([]byte("0"))
+	if r := sk.Rank([]byte("c")); r != 1 {
+		t.Fatalf("Expected rank 1 got %v", r)
+	}
+
+	sk.Put([]byte("b"), nil)
+	if key, _ := sk.Select(1); string(key) != "b" || sk.CountRange([]byte("a"), []byte("c")) != 3 {
+		t.Fatal("Writing the key again should count it")
+	}
+}
+
+func TestRankMixed(t *testing.T) {
+	rnd := rand.New(rand.NewSource(9))
+	sk := NewWithConf(&Conf{Source: rand.NewSource(9)})
+	model := map[int]bool{}
+
+	var sn *Snapshot
+	for i := 0; i < 3000; i++ {
+		k := rnd.Intn(500)
+		key := []byte(fmt.Sprintf("%03d", k))
+
+		switch rnd.Intn(4) {
+		case 0:
+			sk.Delete(key)
+			delete(model, k)
+		case 1:
+			sk.Remove(key)
+			delete(model, k)
+		default:
+			sk.Put(key, key)
+			model[k] = true
+		}
+
+		// removals under a snapshot keep the deleted nodes linked
+		if i%500 == 0 {
+			if sn != nil {
+				sn.Release()
+			}
+			sn = sk.Snapshot()
+		}
+	}
+	defer sn.Release()
+
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []int
+	for k := range model {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	if sk.Size() != uint(len(keys)) {
+		t.Fatalf("Expected %v keys got %v", len(keys), sk.Size())
+	}
+
+	for i, k := range keys {
+		key := []byte(fmt.Sprintf("%03d", k))
+		if r := sk.Rank(key); r != i {
+			t.Fatalf("Rank of %s should be %v got %v", key, i, r)
+		}
+		if found, ok := sk.Select(i); !ok || string(found) != string(key) {
+			t.Fatalf("Select %v should be %s got %s", i, key, found)
+		}
+	}
+	if _, ok := sk.Select(len(keys)); ok {
+		t.Fatal("Select past the size should fail")
+	}
+	if n := sk.CountRange([]byte("100"), []byte("299")); n != sort.SearchInts(keys, 300)-sort.SearchInts(keys, 100) {
+		t.Fatalf("Wrong count %v", n)
+	}
+}
+
+func TestRankMultiDeleted(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true})
+	for i := 0; i < 30; i++ {
+		sk.Put([]byte(fmt.Sprintf("%02d", i/3)), []byte{byte(i)})
+	}
+
+	sk.Delete([]byte("04"))
+	sn := sk.Snapshot()
+	defer sn.Release()
+	sk.RemoveOne([]byte("06"), []byte{19})
+
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	// 21 entries go before 07, the 3 of 04 and one of 06 are deleted
+	if r := sk.Rank([]byte("07")); r != 17 {
+		t.Fatalf("Expected rank 17 got %v", r)
+	}
+	if key, _ := sk.Select(17); string(key) != "07" {
+		t.Fatalf("Select 17 should be 07 got %s", key)
+	}
+	if n := sk.CountRange([]byte("04"), []byte("06")); n != 5 {
+		t.Fatalf("Expected 5 entries got %v", n)
+	}
+}
@@ -0,0 +1,253 @@
+package skiplist
+
+import "encoding/binary"
+
+// Union, Intersect and Difference stream the keys of two iterators as
+// sorted sets, both iterators are moved to their first key and must order
+// the keys the same way. Each visits the resulting keys in order until visit
+// returns false and returns the number of keys visited, the iterators are
+// left to the caller to close. The keys and values given to visit point into
+// the lists and must not be modified
+
+// Union visits the keys found in a or b, the value of a wins when a key is
+// in both
+func Union(a, b *Iterator, visit func(key []byte, value []byte) bool) int {
+	cmp := a.list.cmp
+
+	a.First()
+	b.First()
+
+	count := 0
+	for a.Valid() || b.Valid() {
+		var it *Iterator
+		switch {
+		case !b.Valid():
+			it = a
+		case !a.Valid():
+			it = b
+		default:
+			c := cmp.Compare(a.Key(), b.Key())
+			if c == 0 {
+				b.Next()
+			}
+			it = a
+			if c > 0 {
+				it = b
+			}
+		}
+
+		count++
+		if !visit(it.Key(), it.Value()) {
+			break
+		}
+		it.Next()
+	}
+
+	return count
+}
+
+// Intersect visits the keys found in both a and b with the values of a, the
+// iterator lagging behind gallops to the key of the other one
+func Intersect(a, b *Iterator, visit func(key []byte, value []byte) bool) int {
+	cmp := a.list.cmp
+
+	a.First()
+	b.First()
+
+	count := 0
+	for a.Valid() && b.Valid() {
+		switch c := cmp.Compare(a.Key(), b.Key()); {
+		case c < 0:
+			a.gallop(b.Key())
+		case c > 0:
+			b.gallop(a.Key())
+		default:
+			count++
+			if !visit(a.Key(), a.Value()) {
+				return count
+			}
+			a.Next()
+			b.Next()
+		}
+	}
+
+	return count
+}
+
+// Difference visits the keys found in a but not in b
+func Difference(a, b *Iterator, visit func(key []byte, value []byte) bool) int {
+	cmp := a.list.cmp
+
+	a.First()
+	b.First()
+
+	count := 0
+	for ; a.Valid(); a.Next() {
+		if b.Valid() && cmp.Compare(b.Key(), a.Key()) < 0 {
+			b.gallop(a.Key())
+		}
+
+		if b.Valid() && cmp.Compare(b.Key(), a.Key()) == 0 {
+			continue
+		}
+
+		count++
+		if !visit(a.Key(), a.Value()) {
+			break
+		}
+	}
+
+	return count
+}
+
+// gallop moves the iterator to the first key greater or equal than key, the
+// following node is tried first and a search is done only when key is
+// further away
+func (it *Iterator) gallop(key []byte) {
+	it.Next()
+	if it.Valid() && it.list.cmp.Compare(it.Key(), key) < 0 {
+		it.SeekGE(key)
+	}
+}
+
+// Merge moves every key of o into s, the values of o replace the ones of the
+// keys already in s and its tombstones and expiry times are carried over. The
+// keys of o are copied into the arena of s and o is left empty. Every entry
+// of o is placed with the finger of s, the entries falling between the same
+// two keys of s are linked one after the other at the tails of the levels
+// without searching, so Merge costs O(|o|) plus a search for each gap between
+// them and appending o at the end of s doesn't walk s. In multi mode the
+// entries of o follow the ones of s they tie with. Every node needed is
+// allocated before either list changes, Merge fails with ErrFull when they
+// would take s to its MemLimit and with ErrArenaFull when s runs out of
+// nodes, leaving both lists unchanged. Neither list may have open iterators,
+// o can't have open snapshots either and both lists must order the keys the
+// same way
+func (s *SkipList) Merge(o *SkipList) error {
+	// a step is an entry of o with the node of s holding it, either its copy
+	// or the node of the same key it is written over along with the node
+	// keeping the current version of it for the snapshots
+	type step struct {
+		from NodeID
+		id   NodeID
+		ver  NodeID
+		dup  bool
+	}
+
+	var (
+		plan []step
+
+		// pending is the size of the values of o still to copy over the
+		// ones of s
+		pending int64
+	)
+
+	undo := func(err error) error {
+		for _, st := range plan {
+			if !st.dup {
+				s.arena.retire(st.id)
+			}
+			if st.ver != 0 {
+				s.arena.retire(st.ver)
+			}
+		}
+		return err
+	}
+
+	full := func() bool {
+		return s.memLimit > 0 && s.arena.inUse()+pending >= s.memLimit
+	}
+
+	for b := o.sentinel.next(0); b != 0; b = o.arena.NodeFromID(b).next(0) {
+		from := o.arena.NodeFromID(b)
+		if !o.live(from) && !from.tombstone.Load() {
+			continue
+		}
+
+		if full() {
+			return undo(ErrFull)
+		}
+
+		key, value := o.arena.KeyFromID(b), o.arena.ValueFromID(b)
+		st := step{from: b}
+		if !s.multi {
+			st.id = s.seek(key, value)
+		}
+
+		var err error
+		if st.id == 0 {
+			st.id, err = s.arena.allocate(key, value, s.pickHeight())
+		} else {
+			st.dup = true
+			node := s.arena.NodeFromID(st.id)
+			if !from.tombstone.Load() {
+				pending += int64(len(value) + binary.MaxVarintLen64)
+			}
+			if (!from.tombstone.Load() || !node.deleted.Load()) && s.retained(node.seq.Load()) {
+				st.ver, err = s.arena.allocate(nil, nil, -1)
+			}
+		}
+		if err != nil {
+			return undo(err)
+		}
+
+		plan = append(plan, st)
+	}
+
+	for _, st := range plan {
+		from := o.arena.NodeFromID(st.from)
+		key, value := o.arena.KeyFromID(st.from), o.arena.ValueFromID(st.from)
+
+		// the finger leaves the stack before key, right after the entry
+		// linked last when both fall between the same keys of s
+		s.seek(key, value)
+		if !st.dup {
+			s.place(st.id, from.expires.Load(), from.tombstone.Load())
+			continue
+		}
+
+		node := s.arena.NodeFromID(st.id)
+		switch {
+		case !from.tombstone.Load():
+			s.rewrite(st.id, st.ver, value, false)
+			node.expires.Store(from.expires.Load())
+		case !node.deleted.Load():
+			s.rewrite(st.id, st.ver, nil, true)
+		}
+
+		if from.tombstone.Load() {
+			node.tombstone.Store(true)
+		}
+	}
+
+	o.clear()
+
+	return nil
+}
+
+// clear retires every node of the list and its versions and empties it
+func (s *SkipList) clear() {
+	for id := s.sentinel.next(0); id != 0; {
+		n := s.arena.NodeFromID(id)
+		for ver := NodeID(n.older.Load()); ver != 0; {
+			older := NodeID(s.arena.NodeFromID(ver).older.Load())
+			s.arena.retire(ver)
+			ver = older
+		}
+
+		next := n.next(0)
+		s.arena.retire(id)
+		id = next
+	}
+
+	for h := range s.sentinel.Next {
+		s.sentinel.setNext(h, 0)
+		s.sentinel.Span[h] = 1
+	}
+
+	s.height.Store(1)
+	s.rewind()
+	s.nodeCount.Store(0)
+	s.deleted.Store(0)
+	s.versioned = make(map[NodeID]struct{})
+}
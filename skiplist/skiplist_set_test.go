@@ -0,0 +1,255 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newSet(from, to, step int, value string) *SkipList {
+	sk := New()
+	for i := from; i < to; i += step {
+		sk.Put([]byte(fmt.Sprintf("%04d", i)), []byte(value))
+	}
+
+	return sk
+}
+
+func collect(t *testing.T, op func(a, b *Iterator, visit func(key []byte, value []byte) bool) int, x, y *SkipList) []string {
+	a, b := x.NewIterator(), y.NewIterator()
+	defer a.Close()
+	defer b.Close()
+
+	var got []string
+	count := op(a, b, func(key []byte, value []byte) bool {
+		got = append(got, string(key)+"="+string(value))
+		return true
+	})
+
+	if count != len(got) {
+		t.Fatalf("Expected count %v got %v", len(got), count)
+	}
+
+	return got
+}
+
+func TestSetOperations(t *testing.T) {
+	x, y := newSet(0, 20, 2, "x"), newSet(0, 20, 3, "y")
+
+	got := collect(t, Union, x, y)
+	if len(got) != 13 || got[0] != "0000=x" || got[1] != "0002=x" || got[2] != "0003=y" {
+		t.Fatalf("Wrong union %v", got)
+	}
+
+	got = collect(t, Intersect, x, y)
+	if fmt.Sprint(got) != "[0000=x 0006=x 0012=x 0018=x]" {
+		t.Fatalf("Wrong intersection %v", got)
+	}
+
+	got = collect(t, Difference, x, y)
+	if fmt.Sprint(got) != "[0002=x 0004=x 0008=x 0010=x 0014=x 0016=x]" {
+		t.Fatalf("Wrong difference %v", got)
+	}
+
+	got = collect(t, Difference, y, x)
+	if fmt.Sprint(got) != "[0003=y 0009=y 0015=y]" {
+		t.Fatalf("Wrong difference %v", got)
+	}
+
+	got = collect(t, Intersect, x, New())
+	if len(got) != 0 {
+		t.Fatalf("Intersection with an empty set should be empty got %v", got)
+	}
+}
+
+func TestIntersectGallop(t *testing.T) {
+	x, y := newSet(0, 10000, 1, "x"), newSet(0, 10000, 1000, "y")
+
+	got := collect(t, Intersect, x, y)
+	if len(got) != 10 || got[9] != "9000=x" {
+		t.Fatalf("Wrong intersection %v", got)
+	}
+
+	a, b := x.NewIterator(), y.NewIterator()
+	defer a.Close()
+	defer b.Close()
+
+	if count := Intersect(a, b, func(key []byte, value []byte) bool { return false }); count != 1 {
+		t.Fatalf("Intersection should stop after the first key got %v", count)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	x, y := newSet(0, 1000, 2, "x"), newSet(0, 1000, 3, "y")
+	y.Remove([]byte("0003"))
+
+	if err := x.Merge(y); err != nil {
+		t.Fatal(err)
+	}
+
+	if y.Size() != 0 || y.Find([]byte("0006")) || !y.Insert([]byte("a")) {
+		t.Fatal("Merged list should be left empty and usable")
+	}
+
+	if x.Size() != 666 {
+		t.Fatalf("Expected 666 keys got %v", x.Size())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		value, ok := x.Get(key)
+		switch {
+		case i == 3:
+			if ok {
+				t.Fatal("Removed key should not be merged")
+			}
+		case i%3 == 0:
+			if !ok || string(value) != "y" {
+				t.Fatalf("Wrong value for %v got %v", string(key), string(value))
+			}
+		case i%2 == 0:
+			if !ok || string(value) != "x" {
+				t.Fatalf("Wrong value for %v got %v", string(key), string(value))
+			}
+		case ok:
+			t.Fatalf("Unexpected key %v", string(key))
+		}
+	}
+
+	for i := 0; i < int(x.Size()); i++ {
+		key, _ := x.Select(i)
+		if x.Rank(key) != i {
+			t.Fatalf("Wrong rank for %v", string(key))
+		}
+	}
+
+	if !x.Remove([]byte("0998")) || !x.Insert([]byte("0997")) {
+		t.Fatal("Merged list should accept updates")
+	}
+}
+
+func TestMergeMemLimit(t *testing.T) {
+	x := NewWithConf(&Conf{MemLimit: 64 * 1024})
+	x.Put([]byte("0000"), []byte("x"))
+
+	y := New()
+	for i := 0; i < 100; i++ {
+		y.Put([]byte(fmt.Sprintf("%04d", i)), make([]byte, 1000))
+	}
+
+	if err := x.Merge(y); err != ErrFull {
+		t.Fatalf("Expected ErrFull got %v", err)
+	}
+	if x.Size() != 1 || y.Size() != 100 || x.Full() {
+		t.Fatal("Failed merge should leave both lists untouched")
+	}
+	if v, _ := x.Get([]byte("0000")); string(v) != "x" {
+		t.Fatalf("Expected x got %s", v)
+	}
+	if err := x.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeArenaFull(t *testing.T) {
+	x := NewWithConf(&Conf{MaxNodes: 11})
+	for i := 0; i < 5; i++ {
+		x.Insert([]byte(fmt.Sprint(i)))
+	}
+
+	y := newSet(0, 10, 1, "y")
+	if err := x.Merge(y); err != ErrArenaFull {
+		t.Fatalf("Expected ErrArenaFull got %v", err)
+	}
+
+	if x.Size() != 5 || y.Size() != 10 {
+		t.Fatal("Failed merge should leave both lists untouched")
+	}
+}
+
+// countingComparator counts the comparisons done through it
+type countingComparator struct {
+	count int
+}
+
+func (c *countingComparator) Compare(a, b []byte) int {
+	c.count++
+	return Bytewise.Compare(a, b)
+}
+
+func TestMergeAppend(t *testing.T) {
+	cmp := &countingComparator{}
+	x := NewWithConf(&Conf{Comparator: cmp})
+	for i := 0; i < 2000; i++ {
+		x.Insert([]byte(fmt.Sprintf("%05d", i)))
+	}
+	y := NewWithConf(&Conf{Comparator: cmp})
+	for i := 2000; i < 2100; i++ {
+		y.Insert([]byte(fmt.Sprintf("%05d", i)))
+	}
+
+	// the keys of y all go at the end of x, linking them must not walk x.
+	// The debug builds verify the list with the comparator after each link
+	x.Put([]byte("00000"), nil)
+	cmp.count = 0
+	if err := x.Merge(y); err != nil {
+		t.Fatal(err)
+	}
+	if !debug && cmp.count > 1000 {
+		t.Fatalf("Merging 100 keys at the end compared %v keys", cmp.count)
+	}
+
+	if err := x.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if x.Size() != 2100 || x.Rank([]byte("02050")) != 2050 {
+		t.Fatalf("Expected 2100 keys got %v", x.Size())
+	}
+}
+
+func TestMergeVersionsFull(t *testing.T) {
+	x := NewWithConf(&Conf{MaxNodes: 8})
+	for i := 0; i < 5; i++ {
+		x.Put([]byte(fmt.Sprint(i)), []byte("x"))
+	}
+	sn := x.Snapshot()
+	defer sn.Release()
+
+	// every key of y replaces one the snapshot sees, which takes a node for
+	// its version
+	y := New()
+	for i := 0; i < 5; i++ {
+		y.Put([]byte(fmt.Sprint(i)), []byte("y"))
+	}
+
+	if err := x.Merge(y); err != ErrArenaFull {
+		t.Fatalf("Expected ErrArenaFull got %v", err)
+	}
+	if y.Size() != 5 {
+		t.Fatal("Failed merge should leave both lists untouched")
+	}
+	for i := 0; i < 5; i++ {
+		if v, _ := x.Get([]byte(fmt.Sprint(i))); string(v) != "x" {
+			t.Fatalf("Expected x got %s", v)
+		}
+	}
+	if err := x.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	x = NewWithConf(&Conf{MaxNodes: 16})
+	for i := 0; i < 5; i++ {
+		x.Put([]byte(fmt.Sprint(i)), []byte("x"))
+	}
+	sn = x.Snapshot()
+	defer sn.Release()
+
+	if err := x.Merge(y); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := x.Get([]byte("3")); string(v) != "y" {
+		t.Fatalf("Expected y got %s", v)
+	}
+	if v, _ := sn.Get([]byte("3")); string(v) != "x" {
+		t.Fatalf("Snapshot should keep x got %s", v)
+	}
+}
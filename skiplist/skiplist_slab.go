@@ -0,0 +1,241 @@
+package skiplist
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+)
+
+const bytesForSlab = 1024 * 1024
+
+// slab is a byte allocator, keys and values are copied into large contiguous
+// chunks so that the list owns them and nodes only hold references. A
+// reference packs the chunk index in the upper 32 bits and the offset inside
+// the chunk in the lower ones, the chunk directory is replaced atomically so
+// that concurrent readers never see it while it changes. Bytes are appended
+// to the current chunk and freed one reference at a time, every chunk counts
+// its live bytes and a chunk left without any is given back once no reader
+// can still be reading it, its directory slot is then reused
+type slab struct {
+	chunks    atomic.Pointer[[][]byte]
+	size      int
+	off       int
+	current   int
+	live      []int
+	slots     []int
+	limbo     []retiredChunk
+	retiring  int64
+	epochs    *epochs
+	used      atomic.Int64
+	allocated atomic.Int64
+}
+
+// retiredChunk is a chunk without live bytes waiting for the readers of the
+// epoch it was retired in
+type retiredChunk struct {
+	index int
+	epoch uint64
+}
+
+func newSlab(size int, epochs *epochs) *slab {
+	if size <= 0 {
+		size = bytesForSlab
+	}
+
+	chunks := [][]byte{make([]byte, size)}
+	s := &slab{size: size, live: []int{0}, epochs: epochs}
+	s.chunks.Store(&chunks)
+	s.allocated.Store(int64(size))
+
+	return s
+}
+
+// alloc copies data into the slab and returns its reference, data bigger than
+// the chunk size gets a chunk of its own. Empty data takes no space
+func (s *slab) alloc(data []byte) uint64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	chunks := *s.chunks.Load()
+	if s.off+len(data) > len(chunks[s.current]) {
+		s.grow(len(data))
+		chunks = *s.chunks.Load()
+	}
+
+	ref := uint64(s.current)<<32 | uint64(s.off)
+	copy(chunks[s.current][s.off:], data)
+	s.off += len(data)
+	s.live[s.current] += len(data)
+	s.used.Add(int64(len(data)))
+
+	return ref
+}
+
+// grow starts a new current chunk able to hold n bytes, in a free slot of the
+// directory when there is one
+func (s *slab) grow(n int) {
+	s.reclaim()
+
+	size := s.size
+	if n > size {
+		size = n
+	}
+
+	chunks := *s.chunks.Load()
+	grown := make([][]byte, len(chunks), len(chunks)+1)
+	copy(grown, chunks)
+
+	prev, index := s.current, len(grown)
+	if len(s.slots) > 0 {
+		index = s.slots[len(s.slots)-1]
+		s.slots = s.slots[:len(s.slots)-1]
+		grown[index] = make([]byte, size)
+	} else {
+		grown = append(grown, make([]byte, size))
+		s.live = append(s.live, 0)
+	}
+	s.chunks.Store(&grown)
+	s.allocated.Add(int64(size))
+
+	s.current, s.off = index, 0
+	if s.live[prev] == 0 {
+		s.retire(prev)
+	}
+}
+
+// free gives back the n bytes referenced by ref, readers may keep reading
+// them until they exit the current epoch
+func (s *slab) free(ref uint64, n int) {
+	if n == 0 {
+		return
+	}
+
+	index := int(ref >> 32)
+	s.live[index] -= n
+	s.used.Add(-int64(n))
+
+	if s.live[index] == 0 && index != s.current {
+		s.retire(index)
+	}
+}
+
+// retire puts a chunk without live bytes in limbo
+func (s *slab) retire(index int) {
+	s.limbo = append(s.limbo, retiredChunk{index, s.epochs.epoch.Load()})
+	s.retiring += int64(len((*s.chunks.Load())[index]))
+	s.reclaim()
+}
+
+// reclaim drops the retired chunks no reader can reach anymore and frees
+// their directory slots
+func (s *slab) reclaim() {
+	n := 0
+	for n < len(s.limbo) && s.epochs.safe(s.limbo[n].epoch) {
+		n++
+	}
+
+	if n == 0 {
+		return
+	}
+
+	chunks := *s.chunks.Load()
+	shrunk := make([][]byte, len(chunks))
+	copy(shrunk, chunks)
+	for _, r := range s.limbo[:n] {
+		s.allocated.Add(-int64(len(shrunk[r.index])))
+		s.retiring -= int64(len(shrunk[r.index]))
+		shrunk[r.index] = nil
+		s.slots = append(s.slots, r.index)
+	}
+	s.chunks.Store(&shrunk)
+
+	s.limbo = append(s.limbo[:0], s.limbo[n:]...)
+}
+
+// sparse returns the chunks worth compacting, the ones less than half full
+// of live bytes, once the chunks kept hold more than twice as many dead bytes
+// as live ones. It returns nil otherwise, the chunks in limbo are already
+// on their way out and a compaction leaves at most as many dead bytes as
+// live ones plus the current chunk
+func (s *slab) sparse() []bool {
+	used := s.used.Load()
+	if dead := s.allocated.Load() - s.retiring - used; dead <= 2*used+2*int64(s.size) {
+		return nil
+	}
+
+	chunks := *s.chunks.Load()
+	sparse := make([]bool, len(chunks))
+	for i, chunk := range chunks {
+		sparse[i] = chunk != nil && i != s.current && s.live[i] < len(chunk)/2
+	}
+
+	return sparse
+}
+
+// bytes returns the n bytes referenced by ref, the slice is capped so that
+// appending to it never overwrites the following data
+func (s *slab) bytes(ref uint64, n int) []byte {
+	chunk := (*s.chunks.Load())[ref>>32]
+	off := int(ref & (1<<32 - 1))
+
+	return chunk[off : off+n : off+n]
+}
+
+// allocValue copies a value prefixed by its length and returns its reference
+// plus one, so that zero stays free to represent a nil value
+func (s *slab) allocValue(value []byte) uint64 {
+	if value == nil {
+		return 0
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(value))
+	buf = append(buf[:binary.PutUvarint(buf, uint64(len(value)))], value...)
+
+	return s.alloc(buf) + 1
+}
+
+// value returns the value stored by allocValue
+func (s *slab) value(ref uint64) []byte {
+	if ref == 0 {
+		return nil
+	}
+
+	ref--
+	chunk := (*s.chunks.Load())[ref>>32]
+	off := int(ref & (1<<32 - 1))
+	size, n := binary.Uvarint(chunk[off:])
+
+	return s.bytes(ref+uint64(n), int(size))
+}
+
+// freeValue gives back a value stored by allocValue
+func (s *slab) freeValue(ref uint64) {
+	if ref == 0 {
+		return
+	}
+
+	ref--
+	chunk := (*s.chunks.Load())[ref>>32]
+	off := int(ref & (1<<32 - 1))
+	size, n := binary.Uvarint(chunk[off:])
+
+	s.free(ref, n+int(size))
+}
+
+// compact copies the keys and values still held by the sparse chunks of the
+// slab to the current one so that the chunks can be given back. It walks
+// every node and its versions, and since it only runs once the dead bytes
+// outnumber twice the live ones the walk is paid by the writes that freed
+// them
+func (s *SkipList) compact() {
+	sparse := s.arena.data.sparse()
+	if sparse == nil {
+		return
+	}
+
+	for id := s.head; id != 0; id = s.arena.NodeFromID(id).next(0) {
+		for ver := id; ver != 0; ver = NodeID(s.arena.NodeFromID(ver).older.Load()) {
+			s.arena.relocate(s.arena.NodeFromID(ver), sparse)
+		}
+	}
+}
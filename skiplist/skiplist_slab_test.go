@@ -0,0 +1,158 @@
+package skiplist
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestSlabLargeValues(t *testing.T) {
+	s := newSlab(16, &epochs{})
+	small := s.allocValue([]byte("small"))
+	large := s.allocValue([]byte("a value bigger than the slab chunk size"))
+	after := s.allocValue([]byte("after"))
+
+	if string(s.value(small)) != "small" || string(s.value(after)) != "after" {
+		t.Fatal("Small values corrupted")
+	}
+	if string(s.value(large)) != "a value bigger than the slab chunk size" {
+		t.Fatal("Large value corrupted")
+	}
+}
+
+func TestSlabFree(t *testing.T) {
+	s := newSlab(16, &epochs{})
+	first := s.alloc([]byte("0123456789"))
+	second := s.alloc([]byte("0123456789"))
+
+	if len(*s.chunks.Load()) != 2 || s.used.Load() != 20 {
+		t.Fatal("Expected two chunks in use")
+	}
+
+	s.free(first, 10)
+	if (*s.chunks.Load())[0] != nil || s.allocated.Load() != 16 {
+		t.Fatal("A chunk without live bytes should be given back")
+	}
+
+	// the free slot is reused by the next chunk
+	third := s.alloc([]byte("0123456789"))
+	if third>>32 != 0 || string(s.bytes(second, 10)) != "0123456789" {
+		t.Fatal("Expected the slot of the first chunk reused")
+	}
+}
+
+func TestSlabEpochs(t *testing.T) {
+	s := newSlab(16, &epochs{})
+	first := s.alloc([]byte("0123456789"))
+	s.alloc([]byte("0123456789"))
+
+	epoch := s.epochs.enter()
+	s.free(first, 10)
+	if string(s.bytes(first, 10)) != "0123456789" {
+		t.Fatal("A chunk was given back while a reader could read it")
+	}
+
+	s.epochs.exit(epoch)
+	s.alloc([]byte("0123456789"))
+	if len(s.limbo) != 0 {
+		t.Fatal("Chunk not given back after the reader exited")
+	}
+}
+
+func TestSlabUpsert(t *testing.T) {
+	sk := NewWithConf(&Conf{MemLimit: 64 * 1024})
+	value := make([]byte, 1000)
+
+	for i := 0; i < 10000; i++ {
+		if _, _, err := sk.Put([]byte("key"), value); err != nil {
+			t.Fatalf("Put %v failed with %v", i, err)
+		}
+	}
+
+	if allocated := sk.arena.data.allocated.Load(); allocated > 3*bytesForSlab {
+		t.Fatalf("Replaced values should be given back, %v bytes allocated", allocated)
+	}
+}
+
+// newSlabList returns a list with small slab chunks
+func newSlabList() *SkipList {
+	sk := New()
+	sk.arena.data = newSlab(4096, &sk.arena.epochs)
+
+	return sk
+}
+
+func TestSlabCompact(t *testing.T) {
+	sk := newSlabList()
+	value := make([]byte, 100)
+	for i := 0; i < 4000; i++ {
+		binary.BigEndian.PutUint32(value, uint32(i))
+		sk.Put([]byte(fmt.Sprintf("%06d", i)), value)
+	}
+
+	sn := sk.Snapshot()
+	defer sn.Release()
+
+	// one key in 20 is left in every chunk, the other ones are replaced
+	for i := 0; i < 4000; i++ {
+		if i%20 == 0 {
+			sk.Put([]byte(fmt.Sprintf("%06d", i)), []byte("new"))
+		} else {
+			sk.Remove([]byte(fmt.Sprintf("%06d", i)))
+		}
+	}
+	sn.Release()
+
+	allocated := sk.arena.data.allocated.Load()
+	sk.Put([]byte("a"), nil)
+	if compacted := sk.arena.data.allocated.Load(); compacted >= allocated/2 {
+		t.Fatalf("Expected the slab compacted, %v bytes allocated before %v after", allocated, compacted)
+	}
+
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4000; i += 20 {
+		if v, ok := sk.Get([]byte(fmt.Sprintf("%06d", i))); !ok || string(v) != "new" {
+			t.Fatalf("Key %v lost by the compaction", i)
+		}
+	}
+}
+
+func TestSlabCompactSnapshot(t *testing.T) {
+	sk := newSlabList()
+	value := make([]byte, 100)
+	for i := 0; i < 4000; i++ {
+		binary.BigEndian.PutUint32(value, uint32(i))
+		sk.Put([]byte(fmt.Sprintf("%06d", i)), value)
+	}
+	for i := 0; i < 4000; i++ {
+		if i%100 != 0 {
+			sk.Remove([]byte(fmt.Sprintf("%06d", i)))
+		}
+	}
+
+	// the versions kept for the snapshot hold the values of the sparse
+	// chunks and move with them
+	sn := sk.Snapshot()
+	defer sn.Release()
+	for i := 0; i < 4000; i += 100 {
+		sk.Remove([]byte(fmt.Sprintf("%06d", i)))
+	}
+
+	allocated := sk.arena.data.allocated.Load()
+	sk.Put([]byte("a"), nil)
+	if sk.arena.data.allocated.Load() >= allocated {
+		t.Fatal("Expected the slab compacted")
+	}
+
+	for i := 0; i < 4000; i += 100 {
+		key := []byte(fmt.Sprintf("%06d", i))
+		if v, ok := sn.Get(key); !ok || binary.BigEndian.Uint32(v) != uint32(i) {
+			t.Fatalf("Snapshot lost the value of %s", key)
+		}
+		if sk.Find(key) {
+			t.Fatalf("Key %s should be removed", key)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package skiplist
+
+import "sort"
+
+// Snapshot is a consistent read only view of a SkipList, it keeps seeing the
+// keys and values as they were when it was taken while the list changes.
+// Every write is numbered by a sequence and a node keeps the older versions
+// of its key as long as an open snapshot may still read them. The entries
+// that had expired when the snapshot was taken are missing from it
+type Snapshot struct {
+	list    *SkipList
+	seq     uint64
+	now     int64
+	release func()
+}
+
+// Snapshot returns a view of the current content of the list, it must be
+// released once done so that the versions it holds can be collected
+func (s *SkipList) Snapshot() *Snapshot {
+	sn := &Snapshot{list: s, seq: s.seq, now: s.clock().UnixNano()}
+	sn.release = func() { s.drop(sn.seq) }
+
+	s.snapshots[s.seq]++
+	s.newest = s.seq
+
+	return sn
+}
+
+// Seq returns the sequence of the last write seen by the snapshot
+func (sn *Snapshot) Seq() uint64 {
+	return sn.seq
+}
+
+// Release drops the snapshot and collects the versions that no other
+// snapshot needs, the snapshot must not be used afterwards
+func (sn *Snapshot) Release() {
+	if sn.release == nil {
+		return
+	}
+
+	sn.release()
+	sn.release = nil
+}
+
+// Find returns true if the key was in the list when the snapshot was taken
+func (sn *Snapshot) Find(key []byte) bool {
+	_, ok := sn.Get(key)
+	return ok
+}
+
+// Get returns the value the key had when the snapshot was taken and true, or
+// nil and false when the key wasn't in the list. The value points into the
+// list and must not be modified
+func (sn *Snapshot) Get(key []byte) ([]byte, bool) {
+	s := sn.list
+	defer s.arena.exit(s.arena.enter())
+
+	n := s.arena.NodeFromID(s.findPrev(key))
+	for n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key) {
+		if ref, ok := s.version(n.next(0), sn.seq, sn.now); ok {
+			return s.arena.data.value(ref), true
+		}
+		n = s.arena.NodeFromID(n.next(0))
+	}
+
+	return nil, false
+}
+
+// NewIterator returns an unpositioned iterator over the snapshot, it has to
+// be closed like the iterators over the list
+func (sn *Snapshot) NewIterator() *Iterator {
+	it := sn.list.NewIterator()
+	it.snap = sn
+
+	return it
+}
+
+// Scan visits in order the keys of the snapshot within b, see SkipList.Scan
+func (sn *Snapshot) Scan(b *Bounds, visit func(key []byte, value []byte) bool) int {
+	return scan(sn.NewIterator(), b, visit)
+}
+
+// Range returns the keys of the snapshot within b, they point into the list
+// and must not be modified
+func (sn *Snapshot) Range(b *Bounds) [][]byte {
+	return keys(sn.NewIterator(), b)
+}
+
+// version returns the reference of the value that node id had at seq and
+// true, or 0 and false when its key was missing, deleted or expired at that
+// point, now being the unix time in nanoseconds of seq. The value, the
+// deleted flag and the expiry time are loaded before the sequence, which
+// writers update first, so a concurrent write sends the reader down the
+// older versions
+func (s *SkipList) version(id NodeID, seq uint64, now int64) (uint64, bool) {
+	for id != 0 {
+		n := s.arena.NodeFromID(id)
+		ref, deleted, expires := n.value.Load(), n.deleted.Load(), n.expires.Load()
+		if n.seq.Load() <= seq {
+			if deleted || (expires != 0 && expires <= now) {
+				return 0, false
+			}
+			return ref, true
+		}
+
+		id = NodeID(n.older.Load())
+	}
+
+	return 0, false
+}
+
+// retained returns true when an open snapshot sees the version written at
+// seq, a newer write then has to keep it
+func (s *SkipList) retained(seq uint64) bool {
+	return len(s.snapshots) > 0 && s.newest >= seq
+}
+
+// revise writes a new version of node id, either a new value or a deletion.
+// The current version is moved to a node without levels when a snapshot
+// still sees it, otherwise it is overwritten in place and its value given
+// back. The stack must hold the nodes preceding id on each level, like
+// locate leaves it, so that the spans follow a deletion
+func (s *SkipList) revise(id NodeID, value []byte, deleted bool) error {
+	var verID NodeID
+	if s.retained(s.arena.NodeFromID(id).seq.Load()) {
+		var err error
+		if verID, err = s.arena.allocate(nil, nil, -1); err != nil {
+			return err
+		}
+	}
+
+	s.rewrite(id, verID, value, deleted)
+
+	return nil
+}
+
+// rewrite is revise with the node taking the current version allocated in
+// verID beforehand when a snapshot still sees it, zero otherwise
+func (s *SkipList) rewrite(id NodeID, verID NodeID, value []byte, deleted bool) {
+	node := s.arena.NodeFromID(id)
+	old := node.value.Load()
+	if verID != 0 {
+		ver := s.arena.NodeFromID(verID)
+		ver.value.Store(node.value.Load())
+		ver.seq.Store(node.seq.Load())
+		ver.deleted.Store(node.deleted.Load())
+		ver.expires.Store(node.expires.Load())
+		ver.older.Store(node.older.Load())
+
+		node.older.Store(uint32(verID))
+		s.versioned[id] = struct{}{}
+		old = 0
+	}
+
+	s.seq++
+	node.seq.Store(s.seq)
+	if !deleted {
+		s.arena.setValue(id, value)
+	}
+
+	if node.deleted.Load() != deleted {
+		node.deleted.Store(deleted)
+		if deleted {
+			s.deleted.Add(1)
+			s.versioned[id] = struct{}{}
+		} else {
+			node.tombstone.Store(false)
+			s.deleted.Add(-1)
+		}
+
+		// every link over the node counts it or not anymore
+		for h := 0; h <= s.Height(); h++ {
+			if deleted {
+				s.stack[h].Span[h]--
+			} else {
+				s.stack[h].Span[h]++
+			}
+		}
+	}
+
+	// a deleted node holds no value, it is dropped after the flag and a new
+	// value is stored before the flag is cleared, liveValue relies on both.
+	// When the values order the entries the node keeps its own, a copy when
+	// the older version took it
+	switch {
+	case deleted && s.byValue() && old == 0:
+		s.arena.setValue(id, s.arena.ValueFromID(id))
+	case deleted && s.byValue():
+		old = 0
+	case deleted:
+		node.value.Store(0)
+	}
+	s.arena.data.freeValue(old)
+}
+
+// drop forgets a released snapshot and collects what it was holding
+func (s *SkipList) drop(seq uint64) {
+	s.snapshots[seq]--
+	if s.snapshots[seq] == 0 {
+		delete(s.snapshots, seq)
+	}
+
+	s.newest = 0
+	for seq := range s.snapshots {
+		if seq > s.newest {
+			s.newest = seq
+		}
+	}
+
+	s.collect()
+}
+
+// collect retires the versions no open snapshot can read and unlinks the
+// deleted nodes left without versions, tombstones excepted
+func (s *SkipList) collect() {
+	seqs := make([]uint64, 0, len(s.snapshots))
+	for seq := range s.snapshots {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	for id := range s.versioned {
+		node := s.arena.NodeFromID(id)
+
+		prev, until := node, node.seq.Load()
+		for verID := NodeID(prev.older.Load()); verID != 0; {
+			ver := s.arena.NodeFromID(verID)
+			older := NodeID(ver.older.Load())
+			if needed(seqs, ver.seq.Load(), until) {
+				prev, until = ver, ver.seq.Load()
+			} else {
+				prev.older.Store(uint32(older))
+				s.arena.retire(verID)
+			}
+			verID = older
+		}
+
+		if node.older.Load() != 0 {
+			continue
+		}
+
+		// a deleted node without versions is missing for every snapshot
+		delete(s.versioned, id)
+		if node.deleted.Load() && !node.tombstone.Load() {
+			s.locate(id)
+			s.unlink(id)
+		}
+	}
+}
+
+// needed returns true when one of the sorted snapshot sequences falls
+// between the write of a version at seq and the newer write at until
+func needed(seqs []uint64, seq uint64, until uint64) bool {
+	i := sort.Search(len(seqs), func(i int) bool { return seqs[i] >= seq })
+	return i < len(seqs) && seqs[i] < until
+}
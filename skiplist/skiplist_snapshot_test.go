@@ -0,0 +1,265 @@
+package skiplist
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	sk := New()
+	sk.Put([]byte("a"), []byte("1"))
+	sk.Put([]byte("b"), []byte("1"))
+	sk.Put([]byte("c"), []byte("1"))
+
+	sn := sk.Snapshot()
+	defer sn.Release()
+
+	sk.Put([]byte("a"), []byte("2"))
+	sk.Remove([]byte("b"))
+	sk.Put([]byte("d"), []byte("2"))
+
+	if value, ok := sn.Get([]byte("a")); !ok || string(value) != "1" {
+		t.Fatalf("Snapshot should see the old value of a got %v", string(value))
+	}
+
+	if !sn.Find([]byte("b")) {
+		t.Fatal("Snapshot should still see b")
+	}
+
+	if sn.Find([]byte("d")) {
+		t.Fatal("Snapshot should not see d")
+	}
+
+	if value, ok := sk.Get([]byte("a")); !ok || string(value) != "2" {
+		t.Fatalf("List should see the new value of a got %v", string(value))
+	}
+
+	if sk.Find([]byte("b")) {
+		t.Fatal("List should not see b")
+	}
+
+	if sk.Size() != 3 {
+		t.Fatalf("Expected 3 keys got %v", sk.Size())
+	}
+
+	got := sn.Range(&Bounds{})
+	if len(got) != 3 || string(got[0]) != "a" || string(got[2]) != "c" {
+		t.Fatalf("Wrong snapshot range %q", got)
+	}
+
+	got = sk.Range(&Bounds{})
+	if len(got) != 3 || string(got[1]) != "c" || string(got[2]) != "d" {
+		t.Fatalf("Wrong list range %q", got)
+	}
+}
+
+func TestSnapshotIterator(t *testing.T) {
+	sk := New()
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i))
+		sk.Put(key, key)
+	}
+
+	sn := sk.Snapshot()
+	defer sn.Release()
+
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i))
+		if i%2 == 0 {
+			sk.Remove(key)
+		} else {
+			sk.Put(key, []byte("new"))
+		}
+	}
+
+	it := sn.NewIterator()
+	defer it.Close()
+
+	count := 0
+	for it.First(); it.Valid(); it.Next() {
+		if !bytes.Equal(it.Key(), it.Value()) {
+			t.Fatalf("Wrong value for %v got %v", string(it.Key()), string(it.Value()))
+		}
+		count++
+	}
+
+	if count != 100 {
+		t.Fatalf("Expected 100 keys got %v", count)
+	}
+
+	count = 0
+	for it.Last(); it.Valid(); it.Prev() {
+		count++
+	}
+
+	if count != 100 {
+		t.Fatalf("Expected 100 keys backward got %v", count)
+	}
+
+	list := sk.NewIterator()
+	defer list.Close()
+
+	count = 0
+	for list.First(); list.Valid(); list.Next() {
+		if string(list.Value()) != "new" {
+			t.Fatalf("Wrong value for %v", string(list.Key()))
+		}
+		count++
+	}
+
+	if count != 50 {
+		t.Fatalf("Expected 50 keys got %v", count)
+	}
+}
+
+func TestSnapshotReinsert(t *testing.T) {
+	sk := New()
+	sk.Put([]byte("a"), []byte("1"))
+
+	first := sk.Snapshot()
+	sk.Remove([]byte("a"))
+
+	second := sk.Snapshot()
+	if !sk.Insert([]byte("a")) {
+		t.Fatal("Insert of a deleted key should succeed")
+	}
+
+	if value, ok := first.Get([]byte("a")); !ok || string(value) != "1" {
+		t.Fatalf("First snapshot should see 1 got %v", string(value))
+	}
+
+	if second.Find([]byte("a")) {
+		t.Fatal("Second snapshot should not see a")
+	}
+
+	if !sk.Find([]byte("a")) {
+		t.Fatal("List should see a")
+	}
+
+	first.Release()
+	second.Release()
+	second.Release()
+}
+
+func TestSnapshotCollect(t *testing.T) {
+	sk := New()
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i))
+		sk.Put(key, key)
+	}
+
+	sn := sk.Snapshot()
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i))
+		sk.Put(key, []byte("one"))
+		sk.Put(key, []byte("two"))
+		sk.Remove(key)
+	}
+
+	// the first overwrite keeps a version, the later writes are not seen
+	// by any snapshot and happen in place
+	if used := sk.arena.current; used != 201 {
+		t.Fatalf("Expected 201 nodes in use got %v", used)
+	}
+
+	if sk.Size() != 0 || sk.nodeCount.Load() != 100 {
+		t.Fatalf("Expected 100 deleted nodes got %v of %v", sk.Size(), sk.nodeCount.Load())
+	}
+
+	sn.Release()
+
+	if sk.nodeCount.Load() != 0 || len(sk.versioned) != 0 {
+		t.Fatalf("Deleted nodes should be collected got %v", sk.nodeCount.Load())
+	}
+
+	if free := len(sk.arena.free[0]); free != 100 {
+		t.Fatalf("Expected 100 free versions got %v", free)
+	}
+}
+
+func TestSnapshotCollectBetween(t *testing.T) {
+	sk := New()
+	key := []byte("k")
+
+	var snaps []*Snapshot
+	for i := 0; i < 4; i++ {
+		sk.Put(key, []byte(fmt.Sprint(i)))
+		snaps = append(snaps, sk.Snapshot())
+	}
+	sk.Put(key, []byte("4"))
+
+	snaps[1].Release()
+	snaps[2].Release()
+
+	for _, i := range []int{0, 3} {
+		if value, ok := snaps[i].Get(key); !ok || string(value) != fmt.Sprint(i) {
+			t.Fatalf("Snapshot %v should see %v got %v", i, i, string(value))
+		}
+	}
+
+	if free := len(sk.arena.free[0]); free != 2 {
+		t.Fatalf("Expected 2 free versions got %v", free)
+	}
+
+	snaps[0].Release()
+	snaps[3].Release()
+
+	if len(sk.versioned) != 0 {
+		t.Fatal("Every version should be collected")
+	}
+}
+
+func TestConcurrentSnapshot(t *testing.T) {
+	sk := NewConcurrent()
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("%04d", i))
+		sk.Put(key, key)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			key := []byte(fmt.Sprintf("%04d", i))
+			if i%3 == 0 {
+				sk.Remove(key)
+			} else {
+				sk.Put(key, []byte("new"))
+			}
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				sn := sk.Snapshot()
+				count := sn.Scan(&Bounds{}, func(key []byte, value []byte) bool {
+					return true
+				})
+
+				if count < 666 {
+					t.Errorf("Snapshot lost keys %v", count)
+				}
+
+				again := sn.Scan(&Bounds{}, func(key []byte, value []byte) bool {
+					return true
+				})
+				if again != count {
+					t.Errorf("Snapshot changed from %v to %v keys", count, again)
+				}
+				sn.Release()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if sk.Size() != 666 {
+		t.Fatalf("Expected 666 keys got %v", sk.Size())
+	}
+}
@@ -0,0 +1,55 @@
+package skiplist
+
+// Stats holds the shape of the SkipList, it helps tuning the level
+// generation parameters against a workload
+type Stats struct {
+	Size          uint
+	Height        int
+	Levels        []int   // Levels[h] is the number of nodes with h+1 levels
+	AvgSearchPath float64 // AvgSearchPath is the average links followed to find a key
+	Bytes         int64   // Bytes is the approximate memory held, see Arena.Bytes
+}
+
+// Stats computes the statistics of the list, it walks every node and searches
+// every key so it costs O(n log n)
+func (s *SkipList) Stats() *Stats {
+	stats := &Stats{
+		Size:   s.Size(),
+		Height: s.Height(),
+		Levels: make([]int, s.Height()+1),
+		Bytes:  s.Bytes(),
+	}
+
+	// every linked node is searched, the deleted ones too
+	path, count := 0, 0
+	for n := s.sentinel; n.isNotNull(s.arena, 0); {
+		id := n.next(0)
+		n = s.arena.NodeFromID(id)
+		stats.Levels[n.height()]++
+		path += s.searchPath(s.arena.KeyFromID(id))
+		count++
+	}
+
+	if count > 0 {
+		stats.AvgSearchPath = float64(path) / float64(count)
+	}
+
+	return stats
+}
+
+// searchPath returns the number of links followed by a search for key, both
+// the forward moves and the level drops
+func (s *SkipList) searchPath(key []byte) int {
+	n, path := s.sentinel, 0
+	for h := s.Height(); h >= 0; h-- {
+		for n.isNotNull(s.arena, h) {
+			path++
+			if s.cmp.Compare(s.arena.KeyFromID(n.next(h)), key) >= 0 {
+				break
+			}
+			n = s.arena.NodeFromID(n.next(h))
+		}
+	}
+
+	return path
+}
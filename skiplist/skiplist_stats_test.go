@@ -0,0 +1,72 @@
+package skiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestLevelsDeterministic(t *testing.T) {
+	build := func() *SkipList {
+		sk := NewWithConf(&Conf{Source: rand.NewSource(42)})
+		for i := 0; i < 1000; i++ {
+			sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+		}
+		return sk
+	}
+
+	a, b := build().Stats(), build().Stats()
+	if a.Height != b.Height || fmt.Sprint(a.Levels) != fmt.Sprint(b.Levels) {
+		t.Fatal("Same source should build the same list")
+	}
+}
+
+func TestLevelsMaxLevel(t *testing.T) {
+	sk := NewWithConf(&Conf{P: 0.9, MaxLevel: 4, Source: rand.NewSource(1)})
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	stats := sk.Stats()
+	if stats.Height != 3 || len(stats.Levels) != 4 {
+		t.Fatalf("Height should be capped at 3 got %v", stats.Height)
+	}
+
+	total := 0
+	for _, n := range stats.Levels {
+		total += n
+	}
+	if total != 1000 || stats.Size != 1000 {
+		t.Fatalf("Histogram should count every node got %v", total)
+	}
+}
+
+func TestStatsSearchPath(t *testing.T) {
+	flat := NewWithConf(&Conf{MaxLevel: 1})
+	tall := NewWithConf(&Conf{Source: rand.NewSource(7)})
+	for i := 0; i < 1000; i++ {
+		flat.Insert([]byte(fmt.Sprintf("%04d", i)))
+		tall.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+
+	if f, s := flat.Stats().AvgSearchPath, tall.Stats().AvgSearchPath; f <= s || s <= 0 {
+		t.Fatalf("Levels should shorten the search path %v %v", f, s)
+	}
+}
+
+func TestStatsSearchPathDeleted(t *testing.T) {
+	sk := NewWithConf(&Conf{MaxLevel: 1})
+	for i := 0; i < 100; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%03d", i)))
+	}
+	before := sk.Stats().AvgSearchPath
+
+	// tombstones are still searched, the average should not grow with them
+	for i := 0; i < 100; i += 2 {
+		sk.Delete([]byte(fmt.Sprintf("%03d", i)))
+	}
+
+	if after := sk.Stats().AvgSearchPath; after != before {
+		t.Fatalf("Expected the average path %v got %v", before, after)
+	}
+}
@@ -0,0 +1,264 @@
+package skiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/levante85/index/store"
+)
+
+// Persistence errors types
+var (
+	ErrBadMagic  = fmt.Errorf("Store does not contain a skiplist")
+	ErrDirty     = fmt.Errorf("Store was not closed cleanly")
+	ErrBadRecord = fmt.Errorf("Store record size not supported")
+)
+
+// payloadHeader is the size of the lengths in front of each payload
+const payloadHeader = 8
+
+// Save writes the list to st in the record format described in index.txt,
+// the store header is followed by a head record holding the sentinel links
+// and by one record per node in key order, then comes the data area with the
+// payloads, each one being the key length, the value length plus one, so that
+// nil values are preserved, the key and the value, followed by the towers
+// that do not fit inline. Next pointers are distances in records. Tombstones
+// are saved with the deleted status while the nodes only kept for snapshots
+// and the expired ones are left out, expiry times are not saved
+func (s *SkipList) Save(st store.Store) error {
+	// order holds the saved nodes in key order and index their record number
+	index := make(map[NodeID]uint64, s.nodeCount.Load()+1)
+	order := make([]NodeID, 0, s.nodeCount.Load()+1)
+	tombstones := 0
+	for id := s.head; id != 0; {
+		n := s.arena.NodeFromID(id)
+		if s.live(n) || n.tombstone.Load() || id == s.head {
+			index[id] = uint64(len(index))
+			order = append(order, id)
+		}
+		if n.tombstone.Load() {
+			tombstones++
+		}
+
+		if n.isNotNull(s.arena, 0) {
+			id = n.next(0)
+		} else {
+			id = 0
+		}
+	}
+	count := len(index)
+
+	hm := store.NewHeaderManager(st)
+	hdr := hm.Header()
+	hdr.StatusOk = store.StatusDirty
+	hdr.NumberOfEntries = uint(count - 1)
+	if err := hm.UpdateHeader(); err != nil {
+		return err
+	}
+
+	var (
+		records = make([]byte, count*store.RecordSize)
+		data    = &bytes.Buffer{}
+		base    = uint64(store.HeaderSize + len(records))
+		record  = &store.SRecord{}
+		lengths = make([]byte, payloadHeader)
+	)
+
+	for i, id := range order {
+		n := s.arena.NodeFromID(id)
+		levels := len(n.Next)
+		if id == s.head {
+			levels = s.Height() + 1
+		}
+
+		record.Status = store.RecordOk
+		if n.deleted.Load() {
+			record.Status = store.RecordDeleted
+		}
+		record.Value = 0
+		if id == s.head {
+			record.Value = uint64(tombstones)
+		}
+		record.Next = record.Next[:0]
+		for h := 0; h < levels; h++ {
+			var dist uint64
+			if next := s.saved(n, h, index); next != 0 {
+				dist = index[next] - uint64(i)
+			}
+			record.Next = append(record.Next, dist)
+		}
+
+		if id != s.head {
+			key, value := s.arena.KeyFromID(id), s.arena.ValueFromID(id)
+			binary.LittleEndian.PutUint32(lengths, uint32(len(key)))
+			binary.LittleEndian.PutUint32(lengths[4:], valueLen(value))
+
+			record.Value = base + uint64(data.Len())
+			data.Write(lengths)
+			data.Write(key)
+			data.Write(value)
+		}
+
+		if !record.Inline() {
+			record.Status |= store.RecordOverflow
+			record.Tower = base + uint64(data.Len())
+			data.Write(store.EncodeTower(record.Next))
+		}
+
+		off := i * store.RecordSize
+		if err := record.Encode(records[off : off+store.RecordSize]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := st.WriteAt(records, store.HeaderSize); err != nil {
+		return err
+	}
+
+	if data.Len() > 0 {
+		if _, err := st.WriteAt(data.Bytes(), int(base)); err != nil {
+			return err
+		}
+	}
+
+	if err := st.Sync(0, int(base)+data.Len()); err != nil {
+		return err
+	}
+
+	hdr.StatusOk = store.StatusOk
+	return hm.UpdateHeader()
+}
+
+// Load reads a list written by Save from st, the configuration must order
+// the keys the same way the saved list did. Load stops with ErrFull once the
+// list reaches its MemLimit
+func Load(st store.Store, config *Conf) (*SkipList, error) {
+	hm := store.NewHeaderManager(st)
+	if err := hm.ReadHeader(); err != nil {
+		return nil, err
+	}
+
+	hdr := hm.Header()
+	if err := checkHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	count := int(hdr.NumberOfEntries) + 1
+	records := make([]byte, count*store.RecordSize)
+	if _, err := st.ReadAt(records, store.HeaderSize); err != nil {
+		return nil, err
+	}
+
+	s := NewWithConf(config)
+	b := newBuilder(s)
+
+	record := &store.SRecord{}
+	for i := 0; i < count; i++ {
+		off := i * store.RecordSize
+		if err := record.Decode(records[off : off+store.RecordSize]); err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		key, value, err := readPayload(st, record.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		// tombstones hold no value unless it orders them
+		deleted := record.Status&store.RecordDeleted != 0
+		if deleted && !s.byValue() {
+			value = nil
+		}
+
+		if s.Full() {
+			return nil, ErrFull
+		}
+
+		id, err := s.arena.allocate(key, value, len(record.Next)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		if deleted {
+			s.arena.NodeFromID(id).deleted.Store(true)
+			s.arena.NodeFromID(id).tombstone.Store(true)
+			s.deleted.Add(1)
+		}
+
+		b.append(id)
+	}
+	b.finish()
+
+	return s, nil
+}
+
+// saved returns the first node following n on level h that is saved in index
+func (s *SkipList) saved(n *Node, h int, index map[NodeID]uint64) NodeID {
+	for n.isNotNull(s.arena, h) {
+		next := n.next(h)
+		if _, ok := index[next]; ok {
+			return next
+		}
+		n = s.arena.NodeFromID(next)
+	}
+
+	return 0
+}
+
+func checkHeader(hdr *store.SHeader) error {
+	magic := [len(hdr.Magic)]byte{}
+	copy(magic[:], store.Magic)
+
+	switch {
+	case hdr.Magic != magic:
+		return ErrBadMagic
+	case hdr.StatusOk != store.StatusOk:
+		return ErrDirty
+	case hdr.RecordSize != store.RecordSize:
+		return ErrBadRecord
+	}
+
+	return nil
+}
+
+// readPayload reads the key and the value at off
+func readPayload(st store.Store, off uint64) (key []byte, value []byte, err error) {
+	lengths := make([]byte, payloadHeader)
+	if _, err = st.ReadAt(lengths, int(off)); err != nil {
+		return nil, nil, err
+	}
+
+	keyLen := int(binary.LittleEndian.Uint32(lengths))
+	valLen := int(binary.LittleEndian.Uint32(lengths[4:]))
+
+	size := keyLen
+	if valLen > 0 {
+		size += valLen - 1
+	}
+
+	buf := make([]byte, size)
+	if _, err = st.ReadAt(buf, int(off)+payloadHeader); err != nil {
+		return nil, nil, err
+	}
+
+	key = buf[:keyLen]
+	if valLen > 0 {
+		value = buf[keyLen:]
+	}
+
+	return key, value, nil
+}
+
+// valueLen returns the length of value plus one so that nil is zero
+func valueLen(value []byte) uint32 {
+	if value == nil {
+		return 0
+	}
+
+	return uint32(len(value)) + 1
+}
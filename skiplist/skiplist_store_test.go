@@ -0,0 +1,237 @@
+package skiplist
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/levante85/index/store"
+)
+
+func newTestStore(t *testing.T, mode int) store.Store {
+	st := store.New(&store.Conf{
+		Name: filepath.Join(t.TempDir(), "index."),
+		Size: store.FileSizeIdx,
+		Mode: mode,
+	})
+	if err := st.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	return st
+}
+
+func TestSaveLoad(t *testing.T) {
+	for _, mode := range []int{store.NORMAL, store.MAPPED} {
+		st := newTestStore(t, mode)
+
+		sk := New()
+		for i := 0; i < 5000; i++ {
+			key := []byte(fmt.Sprintf("%05d", i))
+			if _, _, err := sk.Put(key, []byte(fmt.Sprintf("value%v", i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sk.Put([]byte("nil"), nil)
+		sk.Put([]byte("empty"), []byte{})
+
+		if err := sk.Save(st); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := Load(st, &Conf{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.Size() != sk.Size() || loaded.Height() != sk.Height() {
+			t.Fatalf("Loaded list differs %v %v", loaded.Size(), loaded.Height())
+		}
+
+		for i := 0; i < 5000; i++ {
+			value, ok := loaded.Get([]byte(fmt.Sprintf("%05d", i)))
+			if !ok || string(value) != fmt.Sprintf("value%v", i) {
+				t.Fatalf("Key %05d not loaded", i)
+			}
+		}
+		if value, ok := loaded.Get([]byte("nil")); !ok || value != nil {
+			t.Fatal("Nil value not preserved")
+		}
+		if value, ok := loaded.Get([]byte("empty")); !ok || value == nil {
+			t.Fatal("Empty value not preserved")
+		}
+
+		if err := st.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadReopened(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "index.")
+	conf := &store.Conf{Name: name, Size: store.FileSizeIdx, Mode: store.NORMAL}
+
+	st := store.New(conf)
+	if err := st.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	sk := New()
+	sk.Insert([]byte("carlo"))
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	st = store.New(conf)
+	if err := st.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	loaded, err := Load(st, &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Find([]byte("carlo")) {
+		t.Fatal("Value saved not found after reopening")
+	}
+}
+
+func TestSaveDeterministic(t *testing.T) {
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Put([]byte(fmt.Sprintf("%04d", i)), []byte(fmt.Sprint(i)))
+	}
+
+	var saved [][]byte
+	for i := 0; i < 2; i++ {
+		st := newTestStore(t, store.NORMAL)
+		if err := sk.Save(st); err != nil {
+			t.Fatal(err)
+		}
+
+		// the header carries the time of the save, the rest must not differ
+		b := make([]byte, 64*1024)
+		if _, err := st.ReadAt(b, store.HeaderSize); err != nil {
+			t.Fatal(err)
+		}
+		saved = append(saved, b)
+		st.Close()
+	}
+
+	if !bytes.Equal(saved[0], saved[1]) {
+		t.Fatal("Saving the same list twice should give the same bytes")
+	}
+}
+
+func TestSaveTwice(t *testing.T) {
+	for _, mode := range []int{store.NORMAL, store.MAPPED} {
+		st := newTestStore(t, mode)
+
+		// the second save rewrites the records and appends past the first
+		sk := New()
+		for n := 100; n <= 1000; n += 900 {
+			for i := 0; i < n; i++ {
+				sk.Put([]byte(fmt.Sprintf("%04d", i)), []byte(fmt.Sprint(i)))
+			}
+			if err := sk.Save(st); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		loaded, err := Load(st, &Conf{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Size() != 1000 {
+			t.Fatalf("Expected 1000 keys got %v", loaded.Size())
+		}
+		if value, ok := loaded.Get([]byte("0999")); !ok || string(value) != "999" {
+			t.Fatal("Value of the second save not found")
+		}
+		st.Close()
+	}
+}
+
+func TestSaveReopened(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "index.")
+	conf := &store.Conf{Name: name, Size: store.FileSizeIdx, Mode: store.NORMAL}
+
+	sk := New()
+	sk.Insert([]byte("carlo"))
+	for i := 0; i < 4; i++ {
+		st := store.New(conf)
+		if err := st.Open(); err != nil {
+			t.Fatal(err)
+		}
+		if err := sk.Save(st); err != nil {
+			t.Fatal(err)
+		}
+		st.Close()
+
+		stat, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Size() != store.FileSizeIdx {
+			t.Fatalf("Expected the file to keep its size got %v", stat.Size())
+		}
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	st := newTestStore(t, store.NORMAL)
+	defer st.Close()
+
+	if _, err := Load(st, &Conf{}); err == nil {
+		t.Fatal("Loading an empty store should fail")
+	}
+}
+
+func TestLoadRank(t *testing.T) {
+	st := newTestStore(t, store.NORMAL)
+	defer st.Close()
+
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%04d", i)))
+	}
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(st, &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i += 7 {
+		if key, _ := loaded.Select(i); string(key) != fmt.Sprintf("%04d", i) {
+			t.Fatalf("Select %v got %v", i, string(key))
+		}
+	}
+	loaded.Insert([]byte("0500a"))
+	if r := loaded.Rank([]byte("0501")); r != 502 {
+		t.Fatalf("Rank after insert should be 502 got %v", r)
+	}
+}
+
+func TestLoadFull(t *testing.T) {
+	st := newTestStore(t, store.NORMAL)
+	defer st.Close()
+
+	sk := New()
+	for i := 0; i < 20000; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%05d", i)))
+	}
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(st, &Conf{MemLimit: 64 << 10}); err != ErrFull {
+		t.Fatalf("Expected ErrFull got %v", err)
+	}
+}
@@ -73,6 +73,56 @@ func TestInsertFindRemove(t *testing.T) {
 	}
 }
 
+func TestPutGet(t *testing.T) {
+	sk := New()
+	if _, replaced, err := sk.Put([]byte("carlo"), []byte("locci")); err != nil || replaced {
+		t.Fatal("Failed to put new key")
+	}
+
+	value, ok := sk.Get([]byte("carlo"))
+	if !ok {
+		t.Fatal("Key put not found")
+	}
+	if !bytes.Equal(value, []byte("locci")) {
+		t.Fatalf("Wrong value %v", string(value))
+	}
+
+	if _, ok := sk.Get([]byte("carla")); ok {
+		t.Fatal("Found key never put")
+	}
+}
+
+func TestPutUpsert(t *testing.T) {
+	sk := New()
+	if _, _, err := sk.Put([]byte("carlo"), []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, replaced, err := sk.Put([]byte("carlo"), []byte("v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replaced || !bytes.Equal(prev, []byte("v1")) {
+		t.Fatal("Previous value not returned")
+	}
+	if sk.Size() != 1 {
+		t.Fatal("Upsert should not add a node")
+	}
+
+	value, _ := sk.Get([]byte("carlo"))
+	if !bytes.Equal(value, []byte("v2")) {
+		t.Fatalf("Wrong value %v", string(value))
+	}
+
+	if sk.Insert([]byte("carlo")) {
+		t.Fatal("Insert of an existing key should fail")
+	}
+
+	if _, _, err := sk.Put([]byte{}, nil); err != ErrEmptyKey {
+		t.Fatal("Empty key should be refused")
+	}
+}
+
 /*
 func TestInsertFindRemoveMulti(t *testing.T) {
 	sk := New()
@@ -187,3 +237,35 @@ func BenchmarkRangeFind(b *testing.B) {
 		}
 	}
 }
+
+func TestPutOwnsBuffers(t *testing.T) {
+	sk := New()
+	key, value := []byte("carlo"), []byte("locci")
+	if _, _, err := sk.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+
+	copy(key, "zzzzz")
+	copy(value, "zzzzz")
+
+	got, ok := sk.Get([]byte("carlo"))
+	if !ok || string(got) != "locci" {
+		t.Fatal("Reused caller buffers changed the list")
+	}
+	if sk.Find([]byte("zzzzz")) {
+		t.Fatal("Reused caller buffers changed the list")
+	}
+
+	if _, _, err := sk.Put([]byte("empty"), []byte{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := sk.Get([]byte("empty")); got == nil || len(got) != 0 {
+		t.Fatal("Empty value should not turn into nil")
+	}
+	if _, _, err := sk.Put([]byte("nil"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := sk.Get([]byte("nil")); !ok || got != nil {
+		t.Fatal("Nil value should stay nil")
+	}
+}
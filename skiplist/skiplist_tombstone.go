@@ -0,0 +1,63 @@
+package skiplist
+
+// Delete writes a tombstone for key, unlike Remove the key stays in the list
+// marked as deleted so that flushing and replication see the deletion. Reads
+// skip tombstones like missing keys, a later write of the key replaces it.
+// In multi mode every entry of the key becomes a tombstone
+func (s *SkipList) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+
+	s.compact()
+	s.search(key)
+
+	found := false
+	for n := s.stack[0]; n.isNotNull(s.arena, 0) && s.eq(s.arena.KeyFromID(n.next(0)), key); {
+		id := n.next(0)
+		n = s.arena.NodeFromID(id)
+		found = true
+
+		if !n.deleted.Load() {
+			if id != s.stack[0].next(0) {
+				s.locate(id)
+			}
+			if err := s.revise(id, nil, true); err != nil {
+				return err
+			}
+		}
+		n.tombstone.Store(true)
+		n.expires.Store(0)
+	}
+
+	if found {
+		return nil
+	}
+
+	if s.Full() {
+		return ErrFull
+	}
+
+	return s.insert(key, nil, 0, true)
+}
+
+// IteratorConf is a configuration struct to be given when a new Iterator is
+// created, the zero value gives the same iterator as NewIterator
+type IteratorConf struct {
+	Tombstones bool // Tombstones stops the iterator on tombstones too, see Deleted
+}
+
+// NewIteratorWithConf returns an unpositioned iterator based on the given
+// configuration, it has to be closed like the ones of NewIterator
+func (s *SkipList) NewIteratorWithConf(config *IteratorConf) *Iterator {
+	it := s.NewIterator()
+	it.tombstones = config.Tombstones
+
+	return it
+}
+
+// Deleted returns true when the iterator stands on a tombstone, only
+// iterators asking for them do
+func (it *Iterator) Deleted() bool {
+	return it.list.arena.NodeFromID(it.id).deleted.Load()
+}
@@ -0,0 +1,151 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/levante85/index/store"
+)
+
+func TestDelete(t *testing.T) {
+	sk := newRangeList()
+
+	if err := sk.Delete([]byte("20")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sk.Delete([]byte("25")); err != nil {
+		t.Fatal(err)
+	}
+
+	if sk.Find([]byte("20")) || sk.Find([]byte("25")) {
+		t.Fatal("Tombstones should not be found")
+	}
+
+	if sk.Size() != 9 || sk.nodeCount.Load() != 11 {
+		t.Fatalf("Expected 9 keys and 11 nodes got %v and %v", sk.Size(), sk.nodeCount.Load())
+	}
+
+	checkRange(t, sk.Range(&Bounds{Upper: []byte("30")}), "00", "10", "30")
+
+	if sk.Remove([]byte("20")) {
+		t.Fatal("Remove of a tombstone should fail")
+	}
+
+	it := sk.NewIteratorWithConf(&IteratorConf{Tombstones: true})
+	var got []string
+	for it.SeekGE([]byte("10")); it.Valid() && string(it.Key()) <= "30"; it.Next() {
+		got = append(got, fmt.Sprint(string(it.Key()), it.Deleted()))
+	}
+	it.Close()
+
+	if fmt.Sprint(got) != "[10false 20true 25true 30false]" {
+		t.Fatalf("Wrong tombstones %v", got)
+	}
+
+	if !sk.Insert([]byte("25")) || !sk.Find([]byte("25")) || sk.Size() != 10 {
+		t.Fatal("Insert should replace a tombstone")
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	sk := newRangeList()
+	sn := sk.Snapshot()
+
+	sk.Remove([]byte("10"))
+	sk.Delete([]byte("20"))
+
+	if !sn.Find([]byte("10")) || !sn.Find([]byte("20")) {
+		t.Fatal("Snapshot should still see the keys")
+	}
+
+	sn.Release()
+
+	if sk.nodeCount.Load() != 9 {
+		t.Fatalf("Only the tombstone should be kept got %v nodes", sk.nodeCount.Load())
+	}
+
+	it := sk.NewIteratorWithConf(&IteratorConf{Tombstones: true})
+	defer it.Close()
+
+	it.SeekGE([]byte("10"))
+	if !it.Valid() || string(it.Key()) != "20" || !it.Deleted() {
+		t.Fatal("Tombstone should be kept after the release")
+	}
+}
+
+func TestDeleteSaveLoad(t *testing.T) {
+	st := newTestStore(t, store.NORMAL)
+
+	sk := newRangeList()
+	sk.Delete([]byte("50"))
+	sk.Delete([]byte("55"))
+
+	sn := sk.Snapshot()
+	sk.Remove([]byte("60"))
+
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	sn.Release()
+
+	loaded, err := Load(st, &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Size() != 8 || loaded.Find([]byte("50")) || loaded.Find([]byte("60")) {
+		t.Fatalf("Wrong loaded list of %v keys", loaded.Size())
+	}
+
+	checkRange(t, loaded.Range(&Bounds{Lower: []byte("40"), Upper: []byte("70")}), "40", "70")
+
+	it := loaded.NewIteratorWithConf(&IteratorConf{Tombstones: true})
+	count := 0
+	for it.First(); it.Valid(); it.Next() {
+		if it.Deleted() {
+			count++
+		}
+	}
+	it.Close()
+
+	if count != 2 {
+		t.Fatalf("Expected 2 tombstones got %v", count)
+	}
+
+	v, err := OpenView(st, &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Size() != 8 {
+		t.Fatalf("Expected 8 keys in view got %v", v.Size())
+	}
+
+	if ok, err := v.Find([]byte("55")); ok || err != nil {
+		t.Fatal("View should skip tombstones")
+	}
+
+	var got []string
+	vit := v.NewIterator()
+	for vit.Last(); vit.Valid(); vit.Prev() {
+		got = append(got, string(vit.Key()))
+	}
+
+	if fmt.Sprint(got) != "[90 80 70 40 30 20 10 00]" {
+		t.Fatalf("Wrong view keys %v", got)
+	}
+}
+
+func TestMergeTombstones(t *testing.T) {
+	x, y := newRangeList(), New()
+	y.Delete([]byte("10"))
+	y.Delete([]byte("15"))
+
+	if err := x.Merge(y); err != nil {
+		t.Fatal(err)
+	}
+
+	if x.Find([]byte("10")) || x.Size() != 9 || x.nodeCount.Load() != 11 {
+		t.Fatal("Tombstones should be merged")
+	}
+}
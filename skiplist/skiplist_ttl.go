@@ -0,0 +1,82 @@
+package skiplist
+
+import "time"
+
+// Entries written with a ttl expire once the Conf.Clock reaches their expiry
+// time, from then on Find, Get, the iterators and the range reads skip them
+// as if they were removed and writing the key again adds it back. Expired
+// nodes stay linked and counted by Size until Sweep unlinks them and gives
+// them back to the arena, snapshots don't look at the expiry and Save leaves
+// the expired entries out without saving the expiry of the others
+
+// PutWithTTL associates value with key like Put does, the entry expires after
+// ttl and never does when ttl is not positive. The previous value points
+// into the list and must not be modified
+func (s *SkipList) PutWithTTL(key []byte, value []byte, ttl time.Duration) (prev []byte, replaced bool, err error) {
+	return s.put(key, value, s.expiry(ttl), true)
+}
+
+// InsertWithTTL inserts key like Insert does, the entry expires after ttl and
+// never does when ttl is not positive
+func (s *SkipList) InsertWithTTL(key []byte, ttl time.Duration) bool {
+	_, found, err := s.put(key, nil, s.expiry(ttl), false)
+	return !found && err == nil
+}
+
+// Sweep removes the expired entries and returns their number, their nodes
+// are reused by later writes once no iterator can reach them
+func (s *SkipList) Sweep() int {
+	var expired []NodeID
+	for n := s.sentinel; n.isNotNull(s.arena, 0); {
+		id := n.next(0)
+		n = s.arena.NodeFromID(id)
+		if !n.deleted.Load() && s.expired(n) {
+			expired = append(expired, id)
+		}
+	}
+
+	for _, id := range expired {
+		s.locate(id)
+		s.remove(id)
+	}
+
+	return len(expired)
+}
+
+// expiry returns the expiry time of an entry written now with ttl, zero when
+// it never expires
+func (s *SkipList) expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	return s.clock().Add(ttl).UnixNano()
+}
+
+// expired returns true when node has an expiry time that has been reached
+func (s *SkipList) expired(node *Node) bool {
+	e := node.expires.Load()
+	return e != 0 && e <= s.clock().UnixNano()
+}
+
+// live returns true when node holds a key of the list, it is neither deleted
+// nor expired
+func (s *SkipList) live(node *Node) bool {
+	return !node.deleted.Load() && !s.expired(node)
+}
+
+// liveValue returns the reference of the value of node and whether node is
+// live, both read at the same point. A deletion sets the flag before it
+// drops the value and a write over a deletion stores the value before it
+// clears the flag, so the flag is loaded on both sides of the value, and
+// since every write stores a new sequence first an unchanged sequence rules
+// out two writes in between
+func (s *SkipList) liveValue(node *Node) (uint64, bool) {
+	for {
+		seq, deleted := node.seq.Load(), node.deleted.Load()
+		ref := node.value.Load()
+		if node.deleted.Load() == deleted && node.seq.Load() == seq {
+			return ref, !deleted && !s.expired(node)
+		}
+	}
+}
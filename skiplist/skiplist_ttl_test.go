@@ -0,0 +1,216 @@
+package skiplist
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Conf.Clock that only moves when advanced
+type fakeClock struct {
+	now atomic.Int64
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{}
+	c.now.Store(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now.Add(int64(d))
+}
+
+func newTTLList(clock *fakeClock) *SkipList {
+	sk := NewWithConf(&Conf{Clock: clock.Now})
+	for i := 0; i < 5; i++ {
+		key := []byte{'0' + byte(i)}
+		if i%2 == 0 {
+			sk.PutWithTTL(key, key, time.Duration(i+1)*time.Second)
+		} else {
+			sk.Put(key, key)
+		}
+	}
+
+	return sk
+}
+
+func TestTTLExpiry(t *testing.T) {
+	clock := newFakeClock()
+	sk := newTTLList(clock)
+
+	checkRange(t, sk.Range(&Bounds{}), "0", "1", "2", "3", "4")
+
+	clock.Advance(3 * time.Second)
+
+	if sk.Find([]byte("0")) || sk.Find([]byte("2")) {
+		t.Fatal("Expired keys should not be found")
+	}
+	if !sk.Find([]byte("4")) || !sk.Find([]byte("1")) {
+		t.Fatal("Keys not expired yet should be found")
+	}
+
+	checkRange(t, sk.Range(&Bounds{}), "1", "3", "4")
+
+	if ok, found := sk.RangeFind([]byte("1"), []byte("3")); !ok || len(found) != 2 {
+		t.Fatalf("Expected 2 keys got %v", len(found))
+	}
+
+	if sk.Remove([]byte("0")) {
+		t.Fatal("Remove of an expired key should fail")
+	}
+
+	if !sk.InsertWithTTL([]byte("0"), 0) || !sk.Find([]byte("0")) {
+		t.Fatal("Insert should replace an expired key")
+	}
+
+	clock.Advance(time.Hour)
+	if !sk.Find([]byte("0")) || sk.Find([]byte("4")) {
+		t.Fatal("Insert without a ttl should clear the expiry")
+	}
+}
+
+func TestTTLPut(t *testing.T) {
+	clock := newFakeClock()
+	sk := NewWithConf(&Conf{Clock: clock.Now})
+
+	sk.PutWithTTL([]byte("a"), []byte("1"), time.Second)
+	prev, replaced, err := sk.PutWithTTL([]byte("a"), []byte("2"), time.Minute)
+	if err != nil || !replaced || string(prev) != "1" {
+		t.Fatalf("Expected to replace 1 got %s %v %v", prev, replaced, err)
+	}
+
+	clock.Advance(time.Second)
+	if v, ok := sk.Get([]byte("a")); !ok || string(v) != "2" {
+		t.Fatal("Put should extend the expiry")
+	}
+
+	clock.Advance(time.Minute)
+	if _, replaced, _ := sk.Put([]byte("a"), []byte("3")); replaced {
+		t.Fatal("Put of an expired key should not replace it")
+	}
+
+	if sk.InsertWithTTL([]byte("a"), time.Second) {
+		t.Fatal("Insert of a present key should fail")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	clock := newFakeClock()
+	sk := newTTLList(clock)
+
+	if sk.Sweep() != 0 {
+		t.Fatal("Nothing should be swept before the expiry")
+	}
+
+	clock.Advance(3 * time.Second)
+
+	if n := sk.Sweep(); n != 2 {
+		t.Fatalf("Expected 2 keys swept got %v", n)
+	}
+	if sk.Size() != 3 || sk.nodeCount.Load() != 3 {
+		t.Fatalf("Expected 3 keys got %v", sk.Size())
+	}
+	if freeNodes(sk.arena) != 2 {
+		t.Fatalf("Expected 2 free nodes got %v", freeNodes(sk.arena))
+	}
+
+	checkRange(t, sk.Range(&Bounds{}), "1", "3", "4")
+	if r := sk.Rank([]byte("4")); r != 2 {
+		t.Fatalf("Expected rank 2 got %v", r)
+	}
+}
+
+func TestSweepSnapshot(t *testing.T) {
+	clock := newFakeClock()
+	sk := newTTLList(clock)
+	sn := sk.Snapshot()
+
+	clock.Advance(time.Hour)
+	if n := sk.Sweep(); n != 3 {
+		t.Fatalf("Expected 3 keys swept got %v", n)
+	}
+
+	if !sn.Find([]byte("0")) || sk.Find([]byte("0")) {
+		t.Fatal("Snapshot should still see the swept key")
+	}
+
+	sn.Release()
+	if sk.nodeCount.Load() != 2 {
+		t.Fatalf("Expected 2 nodes got %v", sk.nodeCount.Load())
+	}
+}
+
+func TestSnapshotExpired(t *testing.T) {
+	clock := newFakeClock()
+	sk := newTTLList(clock)
+	before := sk.Snapshot()
+	defer before.Release()
+
+	clock.Advance(time.Second)
+	after := sk.Snapshot()
+	defer after.Release()
+
+	sk.Put([]byte("0"), []byte("new"))
+	clock.Advance(time.Hour)
+
+	if v, ok := before.Get([]byte("0")); !ok || string(v) != "0" {
+		t.Fatal("Snapshot should see the key not expired when it was taken")
+	}
+	if after.Find([]byte("0")) {
+		t.Fatal("Snapshot should miss the key expired when it was taken")
+	}
+
+	var keys []string
+	after.Scan(&Bounds{}, func(key []byte, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if len(keys) != 4 || keys[0] != "1" {
+		t.Fatalf("Expected every key but 0 got %v", keys)
+	}
+	if n := before.Scan(&Bounds{}, func([]byte, []byte) bool { return true }); n != 5 {
+		t.Fatalf("Expected 5 keys got %v", n)
+	}
+}
+
+func TestSweepMulti(t *testing.T) {
+	clock := newFakeClock()
+	sk := NewWithConf(&Conf{Clock: clock.Now, Multi: true})
+
+	sk.Put([]byte("k"), []byte("1"))
+	sk.PutWithTTL([]byte("k"), []byte("2"), time.Second)
+	sk.Put([]byte("k"), []byte("3"))
+
+	clock.Advance(time.Second)
+	if found := sk.FindAll([]byte("k")); len(found) != 2 {
+		t.Fatalf("Expected 2 entries got %v", len(found))
+	}
+
+	if sk.Sweep() != 1 || sk.Size() != 2 {
+		t.Fatal("Expected the expired entry swept")
+	}
+}
+
+func TestSweeper(t *testing.T) {
+	clock := newFakeClock()
+	sk := NewConcurrentWithConf(&Conf{Clock: clock.Now})
+
+	sk.PutWithTTL([]byte("a"), nil, time.Second)
+	sk.Put([]byte("b"), nil)
+
+	stop := sk.StartSweeper(time.Millisecond)
+	defer stop()
+
+	clock.Advance(time.Second)
+	for i := 0; sk.Size() != 1; i++ {
+		if i == 1000 {
+			t.Fatal("Sweeper should remove the expired key")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
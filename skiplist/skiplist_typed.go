@@ -0,0 +1,310 @@
+package skiplist
+
+import (
+	"math/rand"
+	"runtime"
+)
+
+// typedNode is the node of a TypedSkipList, the key and the value are held in
+// the node itself instead of being copied into a slab
+type typedNode[K, V any] struct {
+	Next  []NodeID
+	key   K
+	value V
+}
+
+// TypedSkipList is a SkipList over keys of type K and values of type V, the
+// keys are ordered by a compare function returning a negative number, zero or
+// a positive number when a is smaller, equal or bigger than b. Nodes live in
+// a pool of buckets like the ones of the Arena so that links stay NodeIDs,
+// and keys and values are kept as they are without being encoded. The list
+// is not safe for concurrent use, BytesSkipList is its []byte instantiation
+type TypedSkipList[K, V any] struct {
+	nodes    pool[typedNode[K, V]]
+	stack    []NodeID
+	head     NodeID
+	height   int
+	count    int
+	compare  func(a, b K) int
+	rnd      *rand.Rand
+	p        float64
+	maxLevel int
+}
+
+// NewTyped creates a new TypedSkipList ordered by compare
+func NewTyped[K, V any](compare func(a, b K) int) *TypedSkipList[K, V] {
+	return NewTypedWithConf[K, V](compare, &Conf{})
+}
+
+// NewTypedWithConf creates a new TypedSkipList ordered by compare, the
+// Comparator, MemLimit and multi mode settings of the configuration don't
+// apply to it
+func NewTypedWithConf[K, V any](compare func(a, b K) int, conf *Conf) *TypedSkipList[K, V] {
+	config := conf.defaults()
+
+	s := &TypedSkipList[K, V]{
+		stack:    make([]NodeID, MaxHeight),
+		compare:  compare,
+		rnd:      rand.New(config.Source),
+		p:        config.P,
+		maxLevel: config.MaxLevel,
+		height:   1,
+	}
+	s.nodes.init(config.BucketSize, config.MaxNodes)
+
+	s.head, _, _ = s.nodes.take(MaxHeight)
+	s.nodes.node(s.head).Next = make([]NodeID, MaxHeight)
+
+	return s
+}
+
+// BytesSkipList is the TypedSkipList over []byte keys and values, it holds
+// the slices it is given which must not be modified afterwards
+type BytesSkipList = TypedSkipList[[]byte, []byte]
+
+// NewBytes creates a new BytesSkipList ordered by the Comparator of the
+// configuration, Bytewise when there is none
+func NewBytes(conf *Conf) *BytesSkipList {
+	cmp := conf.Comparator
+	if cmp == nil {
+		cmp = Bytewise
+	}
+
+	return NewTypedWithConf[[]byte, []byte](cmp.Compare, conf)
+}
+
+// Size returns the number of keys in the list
+func (s *TypedSkipList[K, V]) Size() uint {
+	return uint(s.count)
+}
+
+// Height returns the current height of the list
+func (s *TypedSkipList[K, V]) Height() int {
+	return s.height
+}
+
+// findPrev returns the id of the last node with a key smaller than key, the
+// head id is returned when there is none
+func (s *TypedSkipList[K, V]) findPrev(key K) NodeID {
+	id, n := s.head, s.nodes.node(s.head)
+	for h := s.height; h >= 0; h-- {
+		for n.Next[h] != 0 && s.compare(s.nodes.node(n.Next[h]).key, key) < 0 {
+			id = n.Next[h]
+			n = s.nodes.node(id)
+		}
+	}
+
+	return id
+}
+
+// Find returns true if the key is in the list
+func (s *TypedSkipList[K, V]) Find(key K) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+// Get returns the value associated with key and true if the key was found,
+// the zero value and false otherwise
+func (s *TypedSkipList[K, V]) Get(key K) (V, bool) {
+	if id := s.nodes.node(s.findPrev(key)).Next[0]; id != 0 {
+		if n := s.nodes.node(id); s.compare(n.key, key) == 0 {
+			return n.value, true
+		}
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Put associates value with key, if the key is already present its value is
+// replaced and the previous one is returned together with replaced set to true
+func (s *TypedSkipList[K, V]) Put(key K, value V) (prev V, replaced bool, err error) {
+	if id := s.search(key); id != 0 {
+		n := s.nodes.node(id)
+		prev, n.value = n.value, value
+		return prev, true, nil
+	}
+
+	height := randomHeight(s.rnd, s.p, s.maxLevel)
+	id, reused, err := s.nodes.take(height + 1)
+	if err != nil {
+		return prev, false, err
+	}
+
+	n := s.nodes.node(id)
+	if !reused {
+		n.Next = make([]NodeID, height+1)
+	}
+	n.key, n.value = key, value
+
+	for h := height; h > s.height; h-- {
+		s.stack[h] = s.head
+	}
+
+	for h := 0; h <= height; h++ {
+		before := s.nodes.node(s.stack[h])
+		n.Next[h] = before.Next[h]
+		before.Next[h] = id
+	}
+
+	if height > s.height {
+		s.height = height
+	}
+	s.count++
+
+	return prev, false, nil
+}
+
+// Remove a key and returns true or false based on success or failure
+func (s *TypedSkipList[K, V]) Remove(key K) bool {
+	id := s.search(key)
+	if id == 0 {
+		return false
+	}
+
+	n := s.nodes.node(id)
+	for h := len(n.Next) - 1; h >= 0; h-- {
+		if prev := s.nodes.node(s.stack[h]); prev.Next[h] == id {
+			prev.Next[h] = n.Next[h]
+		}
+	}
+
+	// the removed node keeps its links for the iterators standing on it, its
+	// key and value are dropped so that they can be collected
+	var zero typedNode[K, V]
+	n.key, n.value = zero.key, zero.value
+	s.nodes.release(id, len(n.Next))
+
+	head := s.nodes.node(s.head)
+	for s.height > 1 && head.Next[s.height] == 0 {
+		s.height--
+	}
+	s.count--
+
+	return true
+}
+
+// search fills the stack with the last node smaller than key on each level,
+// it returns the node holding key if any
+func (s *TypedSkipList[K, V]) search(key K) NodeID {
+	id, n := s.head, s.nodes.node(s.head)
+	for h := s.height; h >= 0; h-- {
+		for n.Next[h] != 0 && s.compare(s.nodes.node(n.Next[h]).key, key) < 0 {
+			id = n.Next[h]
+			n = s.nodes.node(id)
+		}
+		s.stack[h] = id
+	}
+
+	if next := n.Next[0]; next != 0 && s.compare(s.nodes.node(next).key, key) == 0 {
+		return next
+	}
+
+	return 0
+}
+
+// TypedIterator is the Iterator counterpart for a TypedSkipList, it keeps the
+// key of its node so that it can still move back once the key is removed
+type TypedIterator[K, V any] struct {
+	list  *TypedSkipList[K, V]
+	id    NodeID
+	key   K
+	epoch uint64
+}
+
+// NewIterator returns an unpositioned iterator over the list, Close must be
+// called once done like for the iterators of SkipList: the removed nodes are
+// not reused while it is open and the garbage collector closes it only when
+// it finalizes a dropped one
+func (s *TypedSkipList[K, V]) NewIterator() *TypedIterator[K, V] {
+	it := &TypedIterator[K, V]{list: s, epoch: s.nodes.enter()}
+	runtime.SetFinalizer(it, (*TypedIterator[K, V]).Close)
+
+	return it
+}
+
+// Close releases the iterator, it must not be used afterwards. Closing it
+// again does nothing
+func (it *TypedIterator[K, V]) Close() {
+	if it.list == nil {
+		return
+	}
+
+	runtime.SetFinalizer(it, nil)
+	it.list.nodes.exit(it.epoch)
+	it.list = nil
+}
+
+// Valid returns true when the iterator is positioned on a node
+func (it *TypedIterator[K, V]) Valid() bool {
+	return it.id != 0 && it.id != it.list.head
+}
+
+// Key returns the key of the current node, the iterator must be valid
+func (it *TypedIterator[K, V]) Key() K {
+	return it.key
+}
+
+// Value returns the value of the current node, the iterator must be valid.
+// It is the zero value once the key has been removed
+func (it *TypedIterator[K, V]) Value() V {
+	return it.list.nodes.node(it.id).value
+}
+
+// move positions the iterator on node id and keeps its key
+func (it *TypedIterator[K, V]) move(id NodeID) {
+	var zero K
+	it.id, it.key = id, zero
+	if it.Valid() {
+		it.key = it.list.nodes.node(id).key
+	}
+}
+
+// First moves the iterator to the smallest key
+func (it *TypedIterator[K, V]) First() {
+	it.move(it.list.nodes.node(it.list.head).Next[0])
+}
+
+// Last moves the iterator to the biggest key
+func (it *TypedIterator[K, V]) Last() {
+	s := it.list
+	id, n := s.head, s.nodes.node(s.head)
+	for h := s.height; h >= 0; h-- {
+		for n.Next[h] != 0 {
+			id = n.Next[h]
+			n = s.nodes.node(id)
+		}
+	}
+
+	it.move(id)
+}
+
+// SeekGE moves the iterator to the first key greater or equal than key
+func (it *TypedIterator[K, V]) SeekGE(key K) {
+	it.move(it.list.nodes.node(it.list.findPrev(key)).Next[0])
+}
+
+// SeekLT moves the iterator to the last key smaller than key
+func (it *TypedIterator[K, V]) SeekLT(key K) {
+	it.move(it.list.findPrev(key))
+}
+
+// Next moves the iterator to the following key, it becomes invalid once
+// the end of the list is reached
+func (it *TypedIterator[K, V]) Next() {
+	if !it.Valid() {
+		return
+	}
+
+	it.move(it.list.nodes.node(it.id).Next[0])
+}
+
+// Prev moves the iterator to the preceding key, it becomes invalid once
+// the beginning of the list is reached
+func (it *TypedIterator[K, V]) Prev() {
+	if !it.Valid() {
+		return
+	}
+
+	it.SeekLT(it.Key())
+}
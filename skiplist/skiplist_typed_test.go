@@ -0,0 +1,162 @@
+package skiplist
+
+import (
+	"cmp"
+	"fmt"
+	"testing"
+)
+
+type sample struct {
+	Min, Max float64
+}
+
+func TestTypedPutGet(t *testing.T) {
+	sk := NewTyped[int64, sample](cmp.Compare[int64])
+	for i := int64(1000); i > 0; i-- {
+		if _, replaced, err := sk.Put(i*10, sample{Min: float64(i)}); err != nil || replaced {
+			t.Fatal("Put of a new key should not replace")
+		}
+	}
+
+	if sk.Size() != 1000 {
+		t.Fatalf("Expected 1000 keys got %v", sk.Size())
+	}
+
+	if value, ok := sk.Get(500); !ok || value.Min != 50 {
+		t.Fatalf("Wrong value for 500 got %v", value)
+	}
+
+	if sk.Find(505) {
+		t.Fatal("505 should not be found")
+	}
+
+	prev, replaced, _ := sk.Put(500, sample{Max: 1})
+	if !replaced || prev.Min != 50 {
+		t.Fatalf("Put should replace the value got %v", prev)
+	}
+
+	for i := int64(1); i <= 1000; i += 2 {
+		if !sk.Remove(i * 10) {
+			t.Fatalf("Failed to remove %v", i*10)
+		}
+	}
+
+	if sk.Remove(10) || sk.Size() != 500 {
+		t.Fatal("Removed keys should be gone")
+	}
+}
+
+func TestTypedIterator(t *testing.T) {
+	sk := NewTyped[string, int](cmp.Compare[string])
+	for i := 0; i < 100; i++ {
+		sk.Put(fmt.Sprintf("%03d", i), i)
+	}
+
+	it := sk.NewIterator()
+	defer it.Close()
+
+	i := 0
+	for it.First(); it.Valid(); it.Next() {
+		if it.Value() != i {
+			t.Fatalf("Expected %v got %v", i, it.Value())
+		}
+		i++
+	}
+
+	if i != 100 {
+		t.Fatalf("Expected 100 keys got %v", i)
+	}
+
+	for it.Last(); it.Valid(); it.Prev() {
+		i--
+		if it.Key() != fmt.Sprintf("%03d", i) {
+			t.Fatalf("Expected %03d got %v", i, it.Key())
+		}
+	}
+
+	it.SeekGE("0505")
+	if !it.Valid() || it.Key() != "051" {
+		t.Fatal("SeekGE should land on 051")
+	}
+
+	sk.Remove("051")
+	it.Next()
+	if !it.Valid() || it.Key() != "052" {
+		t.Fatal("Iterator should move on from a removed node")
+	}
+}
+
+func TestTypedRemoveDrops(t *testing.T) {
+	sk := NewTyped[int, *[]byte](cmp.Compare[int])
+	for i := 0; i < 10; i++ {
+		buf := make([]byte, 1024)
+		sk.Put(i, &buf)
+	}
+
+	it := sk.NewIterator()
+	defer it.Close()
+
+	it.SeekGE(5)
+	id := it.id
+	sk.Remove(5)
+
+	if n := sk.nodes.node(id); n.key != 0 || n.value != nil {
+		t.Fatal("Remove should drop the key and value of the node")
+	}
+	if it.Key() != 5 || it.Value() != nil {
+		t.Fatalf("Expected the removed key 5 without value got %v", it.Key())
+	}
+
+	it.Prev()
+	if !it.Valid() || it.Key() != 4 {
+		t.Fatal("Iterator should move back from a removed node")
+	}
+}
+
+func TestTypedReuse(t *testing.T) {
+	sk := NewTypedWithConf[int, int](cmp.Compare[int], &Conf{MaxNodes: 101})
+	for i := 0; i < 100; i++ {
+		if _, _, err := sk.Put(i, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := sk.Put(100, 100); err != ErrArenaFull {
+		t.Fatalf("Expected ErrArenaFull got %v", err)
+	}
+
+	for i := 0; i < 100; i += 2 {
+		sk.Remove(i)
+	}
+
+	free := 0
+	for _, ids := range sk.nodes.free {
+		free += len(ids)
+	}
+
+	if free != 50 || sk.Size() != 50 {
+		t.Fatalf("Expected 50 free nodes got %v", free)
+	}
+}
+
+func TestBytesSkipList(t *testing.T) {
+	sk := NewBytes(&Conf{Comparator: Reverse(Bytewise)})
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%03d", i))
+		if _, _, err := sk.Put(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if value, ok := sk.Get([]byte("042")); !ok || string(value) != "042" {
+		t.Fatalf("Expected 042 got %s", value)
+	}
+
+	it := sk.NewIterator()
+	defer it.Close()
+
+	it.First()
+	if !it.Valid() || string(it.Key()) != "099" {
+		t.Fatal("Keys should follow the configured Comparator")
+	}
+}
@@ -0,0 +1,139 @@
+package skiplist
+
+import "fmt"
+
+// Verify errors types
+var (
+	ErrCorrupt = fmt.Errorf("SkipList is corrupt")
+)
+
+// Verify walks every level of the list and checks that the keys are in
+// strictly ascending order, ties being allowed in multi mode in their
+// tiebreak order, that every node of a level is also linked on the level
+// below, that the spans match the number of entries not deleted between the
+// nodes and that the node count matches the nodes linked on level 0. The first
+// violation found is returned as an ErrCorrupt wrapping error naming the
+// offending NodeIDs, Verify doesn't modify the list and must not run alongside
+// writers. Builds with the skiplistdebug tag run it after every change of the
+// links and panic on the first corruption
+func (s *SkipList) Verify() error {
+	// pos holds the position of every node of level 0 and rank the number
+	// of nodes not deleted up to it, the sentinel is 0 for both
+	pos := map[NodeID]int{s.head: 0}
+	rank := map[NodeID]int{s.head: 0}
+
+	count, live := 0, 0
+	for prev, id := s.head, s.sentinel.next(0); id != 0; prev, id = id, s.arena.NodeFromID(id).next(0) {
+		if err := s.verifyNode(prev, id, 0); err != nil {
+			return err
+		}
+		if _, ok := pos[id]; ok {
+			return fmt.Errorf("%w: level 0 loops back from node %d to node %d", ErrCorrupt, prev, id)
+		}
+
+		count++
+		live += int(s.arena.NodeFromID(id).weight())
+		pos[id], rank[id] = count, live
+	}
+
+	if n := s.nodeCount.Load(); n != uint64(count) {
+		return fmt.Errorf("%w: node count is %d but level 0 links %d nodes", ErrCorrupt, n, count)
+	}
+
+	// the end of every level is one past the last node
+	rank[0] = live + 1
+	for h := 0; h <= s.Height(); h++ {
+		if err := s.verifyLevel(h, pos, rank); err != nil {
+			return err
+		}
+	}
+
+	for h := s.Height() + 1; h < len(s.sentinel.Next); h++ {
+		if id := s.sentinel.next(h); id != 0 {
+			return fmt.Errorf("%w: level %d above height %d links node %d", ErrCorrupt, h, s.Height(), id)
+		}
+	}
+
+	return nil
+}
+
+// verifyLevel checks the order and the spans of level h and that its nodes
+// are linked on level h-1, pos and rank hold the positions and the ranks
+// found on level 0
+func (s *SkipList) verifyLevel(h int, pos map[NodeID]int, rank map[NodeID]int) error {
+	below := s.head
+	for prev, id := s.head, s.sentinel.next(h); ; prev, id = id, s.arena.NodeFromID(id).next(h) {
+		if id != 0 {
+			if err := s.verifyNode(prev, id, h); err != nil {
+				return err
+			}
+
+			p, ok := pos[id]
+			if !ok {
+				return fmt.Errorf("%w: node %d of level %d is not linked on level 0", ErrCorrupt, id, h)
+			}
+			if p <= pos[prev] {
+				return fmt.Errorf("%w: level %d loops back from node %d to node %d", ErrCorrupt, h, prev, id)
+			}
+		}
+
+		if span, want := int(s.arena.NodeFromID(prev).Span[h]), rank[id]-rank[prev]; span != want {
+			return fmt.Errorf("%w: node %d has span %d on level %d, expected %d", ErrCorrupt, prev, span, h, want)
+		}
+
+		if id == 0 {
+			return nil
+		}
+
+		if h > 0 {
+			for below != id {
+				if below = s.arena.NodeFromID(below).next(h - 1); below == 0 || pos[below] > pos[id] {
+					return fmt.Errorf("%w: node %d of level %d is not linked on level %d", ErrCorrupt, id, h, h-1)
+				}
+			}
+		}
+	}
+}
+
+// verifyNode checks that node id can follow node prev on level h
+func (s *SkipList) verifyNode(prev NodeID, id NodeID, h int) error {
+	if int(id) > s.arena.current {
+		return fmt.Errorf("%w: node %d links node %d on level %d outside of the arena", ErrCorrupt, prev, id, h)
+	}
+
+	node := s.arena.NodeFromID(id)
+	switch {
+	case node.height() < h:
+		return fmt.Errorf("%w: node %d of height %d is linked on level %d by node %d", ErrCorrupt, id, node.height(), h, prev)
+	case node.keyLen == 0:
+		return fmt.Errorf("%w: node %d linked on level %d by node %d has no key", ErrCorrupt, id, h, prev)
+	case prev == s.head:
+		return nil
+	}
+
+	c := s.cmp.Compare(s.arena.KeyFromID(prev), s.arena.KeyFromID(id))
+	if c == 0 && s.multi {
+		if s.tiebreak == nil || s.tiebreak.Compare(s.arena.ValueFromID(prev), s.arena.ValueFromID(id)) <= 0 {
+			return nil
+		}
+	}
+
+	if c >= 0 {
+		return fmt.Errorf("%w: node %d is not ordered after node %d on level %d", ErrCorrupt, id, prev, h)
+	}
+
+	return nil
+}
+
+// verify panics when the list is corrupt in builds with the skiplistdebug
+// tag, it is called after every change of the links and does nothing in the
+// other builds
+func (s *SkipList) verify() {
+	if !debug {
+		return
+	}
+
+	if err := s.Verify(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,116 @@
+package skiplist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func newVerifyList(t *testing.T) *SkipList {
+	t.Helper()
+
+	sk := NewWithConf(&Conf{Source: rand.NewSource(1)})
+	for i := 0; i < 200; i++ {
+		sk.Insert([]byte(fmt.Sprintf("%03d", i)))
+	}
+
+	// a node above level 0 gives the corruptions something to break
+	if sk.sentinel.next(1) == 0 {
+		t.Fatal("Expected nodes on level 1")
+	}
+
+	return sk
+}
+
+func checkCorrupt(t *testing.T, sk *SkipList) {
+	t.Helper()
+
+	err := sk.Verify()
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Expected a corruption got %v", err)
+	}
+	t.Log(err)
+}
+
+func TestVerify(t *testing.T) {
+	sk := newVerifyList(t)
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	sn := sk.Snapshot()
+	for i := 0; i < 200; i += 3 {
+		sk.Remove([]byte(fmt.Sprintf("%03d", i)))
+	}
+	sk.Delete([]byte("001"))
+	sk.RemoveRange([]byte("100"), []byte("150"))
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	sn.Release()
+	sk.RemoveRange([]byte("000"), []byte("199"))
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New().Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyMulti(t *testing.T) {
+	sk := NewWithConf(&Conf{Multi: true, Tiebreak: Bytewise})
+	for _, v := range []string{"3", "1", "2"} {
+		sk.Put([]byte("k"), []byte(v))
+	}
+	if err := sk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	first := sk.sentinel.next(0)
+	sk.arena.setValue(first, []byte("9"))
+	checkCorrupt(t, sk)
+}
+
+func TestVerifyOrder(t *testing.T) {
+	sk := newVerifyList(t)
+
+	id := sk.sentinel.next(0)
+	sk.arena.setKey(id, []byte("500"))
+	checkCorrupt(t, sk)
+}
+
+func TestVerifyCount(t *testing.T) {
+	sk := newVerifyList(t)
+
+	sk.nodeCount.Add(1)
+	checkCorrupt(t, sk)
+}
+
+func TestVerifyLevels(t *testing.T) {
+	sk := newVerifyList(t)
+
+	// skip a node of level 1 on level 0 only
+	id := sk.sentinel.next(1)
+	prev := sk.arena.NodeFromID(sk.before(id))
+	prev.setNext(0, sk.arena.NodeFromID(id).next(0))
+	sk.nodeCount.Add(^uint64(0))
+
+	checkCorrupt(t, sk)
+}
+
+func TestVerifySpan(t *testing.T) {
+	sk := newVerifyList(t)
+
+	sk.sentinel.Span[1]++
+	checkCorrupt(t, sk)
+}
+
+func TestVerifyHeight(t *testing.T) {
+	sk := newVerifyList(t)
+
+	sk.sentinel.setNext(sk.Height()+1, sk.sentinel.next(0))
+	checkCorrupt(t, sk)
+}
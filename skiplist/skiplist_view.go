@@ -0,0 +1,376 @@
+package skiplist
+
+import (
+	"encoding/binary"
+
+	"github.com/levante85/index/store"
+)
+
+// View is a read only SkipList queried in place from a store written by Save,
+// nodes are never loaded in memory: NodeIDs resolve to record offsets and the
+// records are decoded from the store as searches touch them. Opened through a
+// read only MappedBackend it starts immediately and its memory grows with the
+// pages touched only
+type View struct {
+	st     store.Store
+	cmp    Comparator
+	eq     func(a, b []byte) bool
+	multi  bool
+	head   *viewNode
+	count  uint
+	height int
+}
+
+// viewNode is a record decoded from the store and the key of its payload,
+// the value stays in the store until valueOf reads it. Reading another record
+// into the node reuses its buffers, the head has no key
+type viewNode struct {
+	id       NodeID
+	record   store.SRecord
+	key      []byte
+	valueLen uint32
+	deleted  bool
+	buf      []byte
+}
+
+// viewNodes are the two nodes a walk alternates between, the one it stands on
+// and the one it reads ahead into
+type viewNodes [2]viewNode
+
+// spare returns the node that n is not
+func (ns *viewNodes) spare(n *viewNode) *viewNode {
+	if n == &ns[0] {
+		return &ns[1]
+	}
+
+	return &ns[0]
+}
+
+// OpenView opens the list saved in st, the configuration must order the keys
+// the same way the saved list did and set Multi when it held duplicates
+func OpenView(st store.Store, config *Conf) (*View, error) {
+	hm := store.NewHeaderManager(st)
+	if err := hm.ReadHeader(); err != nil {
+		return nil, err
+	}
+
+	hdr := hm.Header()
+	if err := checkHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	cmp := config.Comparator
+	if cmp == nil {
+		cmp = Bytewise
+	}
+
+	v := &View{
+		st:    st,
+		cmp:   cmp,
+		eq:    equality(cmp),
+		multi: config.Multi,
+		count: hdr.NumberOfEntries,
+	}
+
+	head := &viewNode{}
+	if err := v.nodeFromID(1, head); err != nil {
+		return nil, err
+	}
+
+	v.head = head
+	v.height = len(head.record.Next) - 1
+	v.count -= uint(head.record.Value)
+
+	return v, nil
+}
+
+// Size returns the number of keys in the view
+func (v *View) Size() uint {
+	return v.count
+}
+
+// Height returns the height of the saved list
+func (v *View) Height() int {
+	return v.height
+}
+
+// nodeFromID reads the record of id and the key of its payload into n, the
+// head being the first record
+func (v *View) nodeFromID(id NodeID, n *viewNode) error {
+	n.id = id
+	n.buf = resize(n.buf, store.RecordSize)
+	if _, err := v.st.ReadAt(n.buf, store.HeaderSize+(int(id)-1)*store.RecordSize); err != nil {
+		return err
+	}
+
+	if err := n.record.Decode(n.buf); err != nil {
+		return err
+	}
+
+	if n.record.Status&store.RecordOverflow != 0 {
+		n.buf = resize(n.buf, 8*len(n.record.Next))
+		if _, err := v.st.ReadAt(n.buf, int(n.record.Tower)); err != nil {
+			return err
+		}
+		store.DecodeTower(n.buf, n.record.Next)
+	}
+
+	n.deleted = n.record.Status&store.RecordDeleted != 0
+	n.key, n.valueLen = n.key[:0], 0
+	if id == 1 {
+		return nil
+	}
+
+	n.buf = resize(n.buf, payloadHeader)
+	if _, err := v.st.ReadAt(n.buf, int(n.record.Value)); err != nil {
+		return err
+	}
+
+	n.key = resize(n.key, int(binary.LittleEndian.Uint32(n.buf)))
+	n.valueLen = binary.LittleEndian.Uint32(n.buf[4:])
+	_, err := v.st.ReadAt(n.key, int(n.record.Value)+payloadHeader)
+
+	return err
+}
+
+// valueOf reads the value of n from the store
+func (v *View) valueOf(n *viewNode) ([]byte, error) {
+	if n.valueLen == 0 {
+		return nil, nil
+	}
+
+	value := make([]byte, n.valueLen-1)
+	if len(value) == 0 {
+		return value, nil
+	}
+
+	if _, err := v.st.ReadAt(value, int(n.record.Value)+payloadHeader+len(n.key)); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// resize returns b with a length of n, reallocated when it is too small
+func resize(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+
+	return b[:n]
+}
+
+// follow reads the next node of n on level h into next, it returns false
+// when there is none
+func (v *View) follow(n *viewNode, h int, next *viewNode) (bool, error) {
+	if h >= len(n.record.Next) || n.record.Next[h] == 0 {
+		return false, nil
+	}
+
+	return true, v.nodeFromID(n.id+NodeID(n.record.Next[h]), next)
+}
+
+// findPrev returns the last node with a key smaller than key, the head is
+// returned when there is none. The nodes walked are read into ns
+func (v *View) findPrev(key []byte, ns *viewNodes) (*viewNode, error) {
+	n := v.head
+	for h := v.height; h >= 0; h-- {
+		for {
+			next := ns.spare(n)
+			ok, err := v.follow(n, h, next)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || v.cmp.Compare(next.key, key) >= 0 {
+				break
+			}
+			n = next
+		}
+	}
+
+	return n, nil
+}
+
+// Find returns true if the key is in the view
+func (v *View) Find(key []byte) (bool, error) {
+	_, ok, err := v.Get(key)
+	return ok, err
+}
+
+// Get looks for a key and returns the value associated with it and true if
+// the key was found, nil and false otherwise. Tombstones are skipped
+func (v *View) Get(key []byte) ([]byte, bool, error) {
+	var ns viewNodes
+	n, err := v.findPrev(key, &ns)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		next := ns.spare(n)
+		ok, err := v.follow(n, 0, next)
+		if err != nil || !ok || !v.eq(next.key, key) {
+			return nil, false, err
+		}
+
+		if !next.deleted {
+			value, err := v.valueOf(next)
+			return value, err == nil, err
+		}
+		n = next
+	}
+}
+
+// ViewIterator is the Iterator counterpart for a View, since reading the
+// store may fail the first error stops the iteration and is kept in Err.
+// Tombstones are skipped
+type ViewIterator struct {
+	view  *View
+	nodes viewNodes
+	node  *viewNode
+	seek  []byte
+	err   error
+}
+
+// NewIterator returns an unpositioned iterator over the view
+func (v *View) NewIterator() *ViewIterator {
+	return &ViewIterator{view: v}
+}
+
+// Valid returns true when the iterator is positioned on a node
+func (it *ViewIterator) Valid() bool {
+	return it.err == nil && it.node != nil && it.node.id != 1
+}
+
+// Err returns the error that stopped the iteration if any
+func (it *ViewIterator) Err() error {
+	return it.err
+}
+
+// Key returns the key of the current node, the iterator must be valid. The
+// key is only valid until the iterator moves
+func (it *ViewIterator) Key() []byte {
+	return it.node.key
+}
+
+// Value reads the value of the current node from the store, the iterator
+// must be valid. A failed read stops the iteration and returns nil
+func (it *ViewIterator) Value() []byte {
+	value, err := it.view.valueOf(it.node)
+	if err != nil {
+		it.err = err
+	}
+
+	return value
+}
+
+// First moves the iterator to the smallest key
+func (it *ViewIterator) First() {
+	it.node = it.view.head
+	it.follow(0)
+	it.skipForward()
+}
+
+// Last moves the iterator to the biggest key
+func (it *ViewIterator) Last() {
+	it.node, it.err = it.view.head, nil
+	for h := it.view.height; h >= 0 && it.err == nil; h-- {
+		for {
+			next := it.nodes.spare(it.node)
+			ok, err := it.view.follow(it.node, h, next)
+			if err != nil {
+				it.err = err
+			}
+			if !ok || err != nil {
+				break
+			}
+			it.node = next
+		}
+	}
+
+	it.skipBackward()
+}
+
+// SeekGE moves the iterator to the first key greater or equal than key
+func (it *ViewIterator) SeekGE(key []byte) {
+	it.node, it.err = it.view.findPrev(key, &it.nodes)
+	if it.err != nil {
+		return
+	}
+
+	it.follow(0)
+	it.skipForward()
+}
+
+// SeekLT moves the iterator to the last key smaller than key
+func (it *ViewIterator) SeekLT(key []byte) {
+	it.node, it.err = it.view.findPrev(key, &it.nodes)
+	it.skipBackward()
+}
+
+// Next moves the iterator to the following key
+func (it *ViewIterator) Next() {
+	if !it.Valid() {
+		return
+	}
+
+	it.follow(0)
+	it.skipForward()
+}
+
+// Prev moves the iterator to the preceding key
+func (it *ViewIterator) Prev() {
+	if !it.Valid() {
+		return
+	}
+
+	it.before()
+	it.skipBackward()
+}
+
+// follow moves the iterator to the next node on level h, off the list when
+// there is none
+func (it *ViewIterator) follow(h int) {
+	next := it.nodes.spare(it.node)
+	ok, err := it.view.follow(it.node, h, next)
+
+	it.node, it.err = nil, err
+	if ok {
+		it.node = next
+	}
+}
+
+// before moves the iterator to the node preceding the current one, the
+// entries sharing its key are walked from the predecessor of the key. The
+// key is copied since the search reads over the buffers of the nodes
+func (it *ViewIterator) before() {
+	id := it.node.id
+	it.seek = append(it.seek[:0], it.node.key...)
+	it.node, it.err = it.view.findPrev(it.seek, &it.nodes)
+
+	for it.err == nil && it.view.multi {
+		next := it.nodes.spare(it.node)
+		ok, err := it.view.follow(it.node, 0, next)
+		if err != nil {
+			it.err = err
+		}
+		if !ok || err != nil || next.id == id || !it.view.eq(next.key, it.seek) {
+			return
+		}
+		it.node = next
+	}
+}
+
+// skipForward moves past the tombstones
+func (it *ViewIterator) skipForward() {
+	for it.Valid() && it.node.deleted {
+		it.follow(0)
+	}
+}
+
+// skipBackward moves back past the tombstones
+func (it *ViewIterator) skipBackward() {
+	for it.Valid() && it.node.deleted {
+		it.before()
+	}
+}
@@ -0,0 +1,181 @@
+package skiplist
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/levante85/index/store"
+)
+
+func TestOpenView(t *testing.T) {
+	conf := &store.Conf{
+		Name: filepath.Join(t.TempDir(), "index."),
+		Size: store.FileSizeIdx,
+		Mode: store.MAPPED,
+	}
+
+	st := store.New(conf)
+	if err := st.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Put([]byte(fmt.Sprintf("%04d", i*2)), []byte(fmt.Sprintf("value%v", i*2)))
+	}
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	conf.ReadOnly = true
+	st = store.New(conf)
+	if err := st.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	v, err := OpenView(st, &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Size() != 1000 || v.Height() != sk.Height() {
+		t.Fatalf("View differs from saved list %v %v", v.Size(), v.Height())
+	}
+
+	value, ok, err := v.Get([]byte("0010"))
+	if err != nil || !ok || string(value) != "value10" {
+		t.Fatal("Value saved not found in view")
+	}
+	if ok, _ := v.Find([]byte("0011")); ok {
+		t.Fatal("Found key never saved")
+	}
+
+	it := v.NewIterator()
+	count := 0
+	for it.First(); it.Valid(); it.Next() {
+		count++
+	}
+	if count != 1000 || it.Err() != nil {
+		t.Fatalf("Expected 1000 keys got %v %v", count, it.Err())
+	}
+
+	it.SeekLT([]byte("0011"))
+	if !it.Valid() || string(it.Key()) != "0010" {
+		t.Fatal("SeekLT should land on the previous key")
+	}
+	it.Prev()
+	if !it.Valid() || string(it.Key()) != "0008" {
+		t.Fatal("Prev should land on the previous key")
+	}
+
+	it.Last()
+	if !it.Valid() || string(it.Key()) != "1998" {
+		t.Fatal("Last should land on the biggest key")
+	}
+}
+
+func TestViewMultiPrev(t *testing.T) {
+	st := newTestStore(t, store.MAPPED)
+	defer st.Close()
+
+	sk := NewWithConf(&Conf{Multi: true})
+	sk.Put([]byte("a"), []byte("0"))
+	for i := 1; i <= 5; i++ {
+		sk.Put([]byte("b"), []byte(fmt.Sprint(i)))
+	}
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := OpenView(st, &Conf{Multi: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found [][]byte
+	it := v.NewIterator()
+	for it.Last(); it.Valid(); it.Prev() {
+		found = append(found, it.Value())
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	checkValues(t, found, "5", "4", "3", "2", "1", "0")
+}
+
+// countingStore counts the bytes read from the store it wraps
+type countingStore struct {
+	store.Store
+	read int
+}
+
+func (c *countingStore) ReadAt(b []byte, off int) (int, error) {
+	c.read += len(b)
+	return c.Store.ReadAt(b, off)
+}
+
+func TestViewLazyValues(t *testing.T) {
+	st := store.New(&store.Conf{
+		Name: filepath.Join(t.TempDir(), "index."),
+		Size: store.FileSizeIdx,
+		Mode: store.MAPPED,
+	})
+	if err := st.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	big := make([]byte, 4096)
+	sk := New()
+	for i := 0; i < 1000; i++ {
+		sk.Put([]byte(fmt.Sprintf("%04d", i)), big)
+	}
+	if err := sk.Save(st); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := &countingStore{Store: st}
+	v, err := OpenView(cs, &Conf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the nodes walked cost their records and keys, the value found its size
+	cs.read = 0
+	value, ok, err := v.Get([]byte("0500"))
+	if err != nil || !ok || len(value) != len(big) {
+		t.Fatal("Value saved not found in view")
+	}
+	if cs.read >= 2*len(big) {
+		t.Fatalf("Get read %v bytes for a value of %v", cs.read, len(big))
+	}
+
+	// a record, the lengths of its payload and a key of 4 bytes
+	it := v.NewIterator()
+	cs.read = 0
+	for it.First(); it.Valid(); it.Next() {
+	}
+	if it.Err() != nil || cs.read > 1000*(store.RecordSize+payloadHeader+4) {
+		t.Fatalf("Scanning the keys read %v bytes %v", cs.read, it.Err())
+	}
+
+	// the walks reuse the buffers of the nodes
+	it.First()
+	allocs := testing.AllocsPerRun(100, func() {
+		if it.Next(); !it.Valid() {
+			it.First()
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("Next allocated %v times", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(100, func() {
+		v.Find([]byte("0500"))
+	})
+	if allocs > 10 {
+		t.Fatalf("Find allocated %v times for %v levels", allocs, v.Height())
+	}
+}
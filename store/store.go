@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"syscall"
@@ -43,14 +44,16 @@ var (
 	ErrZeroSlice = fmt.Errorf("Byte slice size must be more than 0")
 	ErrNoData    = fmt.Errorf("Offset must be within valid data region")
 	ErrSizeLimit = fmt.Errorf("Store max size limit of 1 tera reached")
+	ErrReadOnly  = fmt.Errorf("Store was opened read only")
 )
 
 // Conf is a configuration struct to be given when a new store is
 // initialized
 type Conf struct {
-	Name string
-	Size int
-	Mode int // Mode decides whether the store is mem mapped store
+	Name     string
+	Size     int
+	Mode     int  // Mode decides whether the store is mem mapped store
+	ReadOnly bool // ReadOnly opens an existing store without writing to it
 }
 
 // New instanciate a new store based on name size and flags and returns
@@ -61,9 +64,10 @@ func New(config *Conf) Store {
 	}
 
 	fstore := &FileBackend{
-		name:    config.Name,
-		size:    config.Size,
-		maxSize: config.Size * 16,
+		name:     config.Name,
+		size:     config.Size,
+		maxSize:  config.Size * 16,
+		readOnly: config.ReadOnly,
 	}
 
 	switch config.Mode {
@@ -77,48 +81,107 @@ func New(config *Conf) Store {
 	return fstore
 }
 
-// FileBackend is the dt responsible for backing the skiplist on disk
+// FileBackend is the dt responsible for backing the skiplist on disk, currPos
+// is the logical end of the data while fileSize is the preallocated size of
+// the file
 type FileBackend struct {
-	file    *os.File
-	name    string
-	size    int
-	currPos int
-	maxSize int
+	file     *os.File
+	name     string
+	size     int
+	currPos  int
+	fileSize int
+	maxSize  int
+	readOnly bool
 }
 
 //Open new FileStore backing
 func (s *FileBackend) Open() (err error) {
 	_, err = os.Stat(s.name)
-	if err != nil {
+	if err != nil && s.readOnly {
+		return err
+	} else if err != nil {
 		s.file, err = os.OpenFile(
 			s.name,
 			os.O_RDWR|os.O_CREATE|os.O_TRUNC,
 			0666,
 		)
+		if err != nil {
+			return err
+		}
 		err = s.Resize(s.size)
 	} else {
+		flag := os.O_RDWR
+		if s.readOnly {
+			flag = os.O_RDONLY
+		}
+
 		s.file, err = os.OpenFile(
 			s.name,
-			os.O_RDWR|os.O_TRUNC,
+			flag,
 			0666,
 		)
+		if err != nil {
+			return err
+		}
+
+		// existing data is kept so that it survives process restarts, the
+		// file was preallocated so the data ends where its holes begin
+		stat, err := s.file.Stat()
+		if err != nil {
+			return err
+		}
+		s.fileSize = int(stat.Size())
+		s.currPos = s.dataEnd()
 	}
 
 	return err
 }
 
+// Whence values of Seek that find the data and the holes of a sparse file
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+// dataEnd returns the end of the last data region of the file, rounded up to
+// the filesystem block, or the file size when holes can't be told apart
+func (s *FileBackend) dataEnd() int {
+	end := int64(0)
+	for off := int64(0); off < int64(s.fileSize); {
+		data, err := s.file.Seek(off, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			break
+		}
+		if err != nil {
+			return s.fileSize
+		}
+
+		hole, err := s.file.Seek(data, seekHole)
+		if err != nil {
+			return s.fileSize
+		}
+		end, off = hole, hole
+	}
+
+	return int(end)
+}
+
 //WriteAt write at said location
 func (s *FileBackend) WriteAt(b []byte, off int) (int, error) {
-	if err := s.Resize(len(b)); err != nil {
-		return -1, err
+	if s.readOnly {
+		return -1, ErrReadOnly
 	}
 
 	if off+len(b) > s.maxSize {
 		return -1, ErrSizeLimit
 	}
 
-	if off >= s.currPos {
-		s.currPos += len(b)
+	if err := s.Resize(off + len(b)); err != nil {
+		return -1, err
+	}
+
+	if off+len(b) > s.currPos {
+		s.currPos = off + len(b)
 	}
 
 	return s.file.WriteAt(b, int64(off))
@@ -129,20 +192,33 @@ func (s *FileBackend) ReadAt(b []byte, off int) (int, error) {
 	return s.file.ReadAt(b, int64(off))
 }
 
-// Resize evaluates the current resize and double the current size to a multiple
-// of filestore size
+// Resize makes the file hold at least size bytes, the file grows to the
+// store size and then doubles up to maxSize
 func (s *FileBackend) Resize(size int) error {
-	if s.currPos+size > s.size {
-		size += s.currPos
-		size /= s.size
-		size *= 2
-		size *= s.size
-
-		return s.file.Truncate(int64(size))
-	} else if s.currPos+size == s.size && s.currPos == 0 {
-		return s.file.Truncate(int64(size))
+	if size <= s.fileSize {
+		return nil
+	}
+
+	if size > s.maxSize {
+		return ErrSizeLimit
+	}
+
+	grown := s.fileSize
+	if grown < s.size {
+		grown = s.size
+	}
+	for grown < size {
+		grown *= 2
+	}
+	if grown > s.maxSize {
+		grown = s.maxSize
 	}
 
+	if err := s.file.Truncate(int64(grown)); err != nil {
+		return err
+	}
+	s.fileSize = grown
+
 	return nil
 }
 
@@ -186,11 +262,16 @@ func (m *MappedBackend) Open() (err error) {
 		return err
 	}
 
+	prot := syscall.PROT_WRITE | syscall.PROT_READ
+	if m.fstore.readOnly {
+		prot = syscall.PROT_READ
+	}
+
 	m.mstore, err = syscall.Mmap(
 		int(m.fstore.file.Fd()),
 		0,
 		int(FileSizeDb),
-		syscall.PROT_WRITE|syscall.PROT_READ,
+		prot,
 		syscall.MAP_SHARED,
 	)
 
@@ -199,8 +280,8 @@ func (m *MappedBackend) Open() (err error) {
 
 //WriteAt write at said location
 func (m *MappedBackend) WriteAt(b []byte, off int) (int, error) {
-	if err := m.fstore.Resize(len(b)); err != nil {
-		return -1, err
+	if m.fstore.readOnly {
+		return -1, ErrReadOnly
 	}
 
 	if len(b) == 0 {
@@ -212,8 +293,12 @@ func (m *MappedBackend) WriteAt(b []byte, off int) (int, error) {
 		return -1, ErrSizeLimit
 	}
 
-	if off >= m.fstore.currPos {
-		m.fstore.currPos += len(b)
+	if err := m.fstore.Resize(off + len(b)); err != nil {
+		return -1, err
+	}
+
+	if off+len(b) > m.fstore.currPos {
+		m.fstore.currPos = off + len(b)
 	}
 
 	for i, j := off, 0; i < off+len(b) && j < len(b); i, j = i+1, j+1 {
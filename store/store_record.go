@@ -1,5 +1,163 @@
 package store
 
-// SRecord represents an index record
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Record layout as described in index.txt
+const (
+	RecordSize   int = 48
+	RecordLevels int = 31
+
+	recordNextOff = 10
+	recordNextLen = 34
+	recordCrcOff  = recordNextOff + recordNextLen
+)
+
+// Record status, the lower byte holds the state of the record while the
+// upper one holds flags about its layout
+const (
+	RecordOk       uint16 = 0
+	RecordDeleted  uint16 = 1
+	RecordOverflow uint16 = 1 << 8
+)
+
+// Record errors types
+var (
+	ErrRecordSize     = fmt.Errorf("Record buffer must be RecordSize bytes")
+	ErrRecordLevels   = fmt.Errorf("Record can have at most RecordLevels levels")
+	ErrRecordOverflow = fmt.Errorf("Record next pointers do not fit inline")
+	ErrRecordChecksum = fmt.Errorf("Record checksum mismatch")
+)
+
+var recordCrc = NewCrc32()
+
+// SRecord represents an index record, Value points to the payload of the
+// record and Next holds, for each level, the distance in records to the
+// following node, zero meaning there is none. Next pointers are compressed
+// as uvarints, when they do not fit in the record they are stored out of line
+// at the Tower offset and the RecordOverflow flag is set
 type SRecord struct {
+	Status uint16
+	Value  uint64
+	Next   []uint64
+	Tower  uint64
+}
+
+// Inline reports whether the next pointers fit inside the record
+func (r *SRecord) Inline() bool {
+	size := 1
+	for _, next := range r.Next {
+		size += uvarintLen(next)
+	}
+
+	return size <= recordNextLen
+}
+
+// Encode writes the record into b, if the next pointers do not fit inline
+// the RecordOverflow flag must be set and Tower must point to them
+func (r *SRecord) Encode(b []byte) error {
+	if len(b) != RecordSize {
+		return ErrRecordSize
+	}
+
+	if len(r.Next) > RecordLevels {
+		return ErrRecordLevels
+	}
+
+	for i := range b {
+		b[i] = 0
+	}
+
+	binary.LittleEndian.PutUint16(b[0:], r.Status)
+	binary.LittleEndian.PutUint64(b[2:], r.Value)
+
+	next := b[recordNextOff:recordCrcOff]
+	next[0] = byte(len(r.Next))
+	switch {
+	case r.Status&RecordOverflow != 0:
+		binary.LittleEndian.PutUint64(next[1:], r.Tower)
+	case r.Inline():
+		off := 1
+		for _, n := range r.Next {
+			off += binary.PutUvarint(next[off:], n)
+		}
+	default:
+		return ErrRecordOverflow
+	}
+
+	binary.LittleEndian.PutUint32(b[recordCrcOff:], recordCrc.Checksum(b[:recordCrcOff]))
+
+	return nil
+}
+
+// Decode reads the record from b, when the RecordOverflow flag is set Next
+// is left with the right length and Tower points to the pointers. Next is
+// reused when it is large enough
+func (r *SRecord) Decode(b []byte) error {
+	if len(b) != RecordSize {
+		return ErrRecordSize
+	}
+
+	if binary.LittleEndian.Uint32(b[recordCrcOff:]) != recordCrc.Checksum(b[:recordCrcOff]) {
+		return ErrRecordChecksum
+	}
+
+	r.Status = binary.LittleEndian.Uint16(b[0:])
+	r.Value = binary.LittleEndian.Uint64(b[2:])
+
+	next := b[recordNextOff:recordCrcOff]
+	if int(next[0]) > RecordLevels {
+		return ErrRecordLevels
+	}
+
+	if cap(r.Next) < int(next[0]) {
+		r.Next = make([]uint64, next[0])
+	}
+	r.Next = r.Next[:next[0]]
+	r.Tower = 0
+	if r.Status&RecordOverflow != 0 {
+		r.Tower = binary.LittleEndian.Uint64(next[1:])
+		return nil
+	}
+
+	off := 1
+	for i := range r.Next {
+		n, size := binary.Uvarint(next[off:])
+		if size <= 0 {
+			return ErrRecordOverflow
+		}
+
+		r.Next[i] = n
+		off += size
+	}
+
+	return nil
+}
+
+// EncodeTower returns the out of line representation of next pointers
+func EncodeTower(next []uint64) []byte {
+	b := make([]byte, 8*len(next))
+	for i, n := range next {
+		binary.LittleEndian.PutUint64(b[8*i:], n)
+	}
+
+	return b
+}
+
+// DecodeTower fills next from its out of line representation
+func DecodeTower(b []byte, next []uint64) {
+	for i := range next {
+		next[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+}
+
+func uvarintLen(n uint64) int {
+	size := 1
+	for ; n >= 0x80; n >>= 7 {
+		size++
+	}
+
+	return size
 }
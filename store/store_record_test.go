@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordEncodeDecode(t *testing.T) {
+	r := &SRecord{
+		Status: RecordOk,
+		Value:  4096,
+		Next:   []uint64{1, 2, 300, 0},
+	}
+
+	b := make([]byte, RecordSize)
+	assert.Nil(t, r.Encode(b))
+
+	out := &SRecord{}
+	assert.Nil(t, out.Decode(b))
+	assert.Equal(t, r, out, "Decoded record should be equal")
+
+	b[3]++
+	assert.Equal(t, ErrRecordChecksum, out.Decode(b), "Corruption should be detected")
+}
+
+func TestRecordOverflow(t *testing.T) {
+	r := &SRecord{Value: 1, Next: make([]uint64, 20)}
+	for i := range r.Next {
+		r.Next[i] = 1 << uint(i+10)
+	}
+
+	b := make([]byte, RecordSize)
+	assert.False(t, r.Inline(), "Next should not fit inline")
+	assert.Equal(t, ErrRecordOverflow, r.Encode(b))
+
+	r.Status |= RecordOverflow
+	r.Tower = 8192
+	assert.Nil(t, r.Encode(b))
+
+	out := &SRecord{}
+	assert.Nil(t, out.Decode(b))
+	assert.Equal(t, uint64(8192), out.Tower, "Tower should be preserved")
+	assert.Equal(t, len(r.Next), len(out.Next), "Height should be preserved")
+
+	DecodeTower(EncodeTower(r.Next), out.Next)
+	assert.Equal(t, r.Next, out.Next, "Tower should round trip")
+}
@@ -5,7 +5,11 @@ import (
 	"encoding/gob"
 	"fmt"
 	"time"
-	"unsafe"
+)
+
+// Header errors types
+var (
+	ErrHeaderSize = fmt.Errorf("Encoded header bigger than HeaderSize")
 )
 
 // Store version and magic
@@ -15,7 +19,7 @@ const (
 	Minor       uint16 = 0
 	StatusOk    uint16 = 0
 	StatusDirty uint16 = 1
-	HeaderSize  int    = 184
+	HeaderSize  int    = 256
 )
 
 //SHeader  is the structure with the statistics from the Store
@@ -43,7 +47,7 @@ func newHeader() *SHeader {
 		VersionMajor: Major,
 		VersionMinor: Minor,
 		StatusOk:     StatusOk,
-		RecordSize:   int(unsafe.Sizeof(SRecord{})),
+		RecordSize:   RecordSize,
 		LastUpdated:  0,
 	}
 	copy(h.Magic[:], Magic)
@@ -55,15 +59,11 @@ func (h *SHeader) calculateUsageStats(s *FileBackend) *SStats {
 	sstas := &SStats{
 		SpaceInUse:      s.currPos,
 		SpaceLeft:       s.maxSize - s.currPos,
-		NumberOfEntries: 0,
+		NumberOfEntries: int(h.NumberOfEntries),
 		RecordSize:      h.RecordSize,
 		LastUpdated:     fmt.Sprintf("%v", time.Unix(h.LastUpdated, 0)),
 	}
 
-	if s.currPos > 0 && h.RecordSize > 0 {
-		sstas.NumberOfEntries = s.currPos - int(unsafe.Sizeof(*h))/h.RecordSize
-	}
-
 	return sstas
 }
 
@@ -78,12 +78,12 @@ type SHeaderManager struct {
 	wBuff   *bytes.Buffer
 	encoder *gob.Encoder
 	decoder *gob.Decoder
-	store   *FileBackend
+	store   Store
 }
 
 // NewHeaderManager instantian a new header manager the performs operations
 // on the store header such as read and update and store statistics
-func NewHeaderManager(s *FileBackend) *SHeaderManager {
+func NewHeaderManager(s Store) *SHeaderManager {
 	return &SHeaderManager{
 		header: newHeader(),
 		rBuff:  &bytes.Buffer{},
@@ -92,20 +92,31 @@ func NewHeaderManager(s *FileBackend) *SHeaderManager {
 	}
 }
 
-// UpdateHeader durably writes the header to the store
+// Header returns the in memory header, UpdateHeader persists its changes
+func (h *SHeaderManager) Header() *SHeader {
+	return h.header
+}
+
+// UpdateHeader durably writes the header to the store, the header is padded
+// to HeaderSize so that it never overlaps with the data following it
 func (h *SHeaderManager) UpdateHeader() error {
 	defer h.wBuff.Reset()
 
-	if h.encoder == nil {
-		h.encoder = gob.NewEncoder(h.wBuff)
-	}
+	// a gob stream carries the type only once so each write needs its own
+	// encoder for the header to be readable by a fresh decoder
+	h.encoder = gob.NewEncoder(h.wBuff)
 
+	h.header.lastUpdated()
 	err := h.encoder.Encode(h.header)
 	if err != nil {
 		return err
 	}
 
-	h.header.lastUpdated()
+	if h.wBuff.Len() > HeaderSize {
+		return ErrHeaderSize
+	}
+
+	h.wBuff.Write(make([]byte, HeaderSize-h.wBuff.Len()))
 	_, err = h.store.WriteAt(h.wBuff.Bytes(), 0)
 	if err != nil {
 		return err
@@ -136,5 +147,17 @@ func (h *SHeaderManager) Stats() (*SStats, error) {
 		return nil, err
 	}
 
-	return h.header.calculateUsageStats(h.store), nil
+	return h.header.calculateUsageStats(fileBackend(h.store)), nil
+}
+
+// fileBackend returns the file backing the store
+func fileBackend(s Store) *FileBackend {
+	switch b := s.(type) {
+	case *MappedBackend:
+		return b.fstore
+	case *FileBackend:
+		return b
+	}
+
+	return &FileBackend{}
 }
@@ -36,7 +36,7 @@ func TestSHeaderUpdate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	assert.Equal(t, ss.SpaceInUse, HeaderSize, "space in use should be 184")
+	assert.Equal(t, ss.SpaceInUse, HeaderSize, "space in use should be HeaderSize")
 	assert.Equal(t, ss.NumberOfEntries, 0, "numer should be 0")
 
 	os.Remove(store.name)
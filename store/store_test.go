@@ -3,6 +3,7 @@ package store
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,7 +100,8 @@ func TestFStoreWriteMany(t *testing.T) {
 		off += len(data)
 	}
 
-	assert.Equal(t, fstore.currPos, 1024*len(data), "curreent offset size wrong")
+	// the writes start one record in, currPos is the end of the last one
+	assert.Equal(t, fstore.currPos, 1025*len(data), "curreent offset size wrong")
 
 	assert.Nil(t, fstore.Close())
 	assert.Nil(t, os.Remove(fstore.name))
@@ -469,3 +471,62 @@ func BenchmarkMStoreWriteLarge(b *testing.B) {
 	assert.Nil(b, fstore.Close())
 	assert.Nil(b, os.Remove(fstore.fstore.name))
 }
+
+func TestMStoreReadOnly(t *testing.T) {
+	conf := &Conf{Name: "index.", Size: FileSizeIdx, Mode: MAPPED}
+	fstore := New(conf)
+	assert.Nil(t, fstore.Open())
+
+	data := []byte("this is a test")
+	_, err := fstore.WriteAt(data, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, fstore.Close())
+
+	conf.ReadOnly = true
+	fstore = New(conf)
+	assert.Nil(t, fstore.Open())
+
+	out := make([]byte, len(data))
+	_, err = fstore.ReadAt(out, 0)
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(data, out), "data should survive reopening")
+
+	_, err = fstore.WriteAt(data, 0)
+	assert.Equal(t, ErrReadOnly, err, "writes should be refused")
+
+	assert.Nil(t, fstore.Close())
+	assert.Nil(t, os.Remove(conf.Name))
+}
+
+func TestFStoreReopen(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "index.")
+	fstore := &FileBackend{name: name, size: FileSizeIdx, maxSize: 4 * FileSizeIdx}
+	assert.Nil(t, fstore.Open())
+
+	data := []byte("this is a test")
+	_, err := fstore.WriteAt(data, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, fstore.Close())
+
+	for i := 0; i < 4; i++ {
+		fstore = &FileBackend{name: name, size: FileSizeIdx, maxSize: 4 * FileSizeIdx}
+		assert.Nil(t, fstore.Open())
+		assert.True(t, fstore.currPos >= len(data) && fstore.currPos < FileSizeIdx, "data end should not be the file size")
+
+		_, err := fstore.WriteAt(data, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, fstore.fileSize, FileSizeIdx, "rewriting should not grow the file")
+		assert.Nil(t, fstore.Close())
+	}
+
+	fstore = &FileBackend{name: name, size: FileSizeIdx, maxSize: 4 * FileSizeIdx}
+	assert.Nil(t, fstore.Open())
+	defer fstore.Close()
+
+	_, err = fstore.WriteAt(data, 3*FileSizeIdx)
+	assert.Nil(t, err)
+	assert.Equal(t, fstore.fileSize, 4*FileSizeIdx, "the file should double up to maxSize")
+
+	_, err = fstore.WriteAt(data, 4*FileSizeIdx)
+	assert.Equal(t, err, ErrSizeLimit)
+}